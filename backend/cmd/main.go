@@ -16,16 +16,23 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 	
 	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/analytics"
 	"techblog-api/backend/internal/api"
 	"techblog-api/backend/internal/config"
 	"techblog-api/backend/internal/database"
 	"techblog-api/backend/internal/handlers"
+	"techblog-api/backend/internal/loginguard"
+	"techblog-api/backend/internal/mailer"
 	"techblog-api/backend/internal/middleware"
 	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/scheduler"
+	"techblog-api/backend/internal/storage"
+	"techblog-api/backend/internal/views"
 )
 
 func main() {
@@ -43,15 +50,74 @@ func main() {
 	// 创建Gin引擎
 	r := gin.New()
 	
+	// 只采信可信代理转发的客户端地址，否则登录限制、浏览量去重和访问统计都可以用伪造的X-Forwarded-For绕过
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	
 	// 全局中间件
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 	r.Use(middleware.CORSMiddleware(cfg))
 	
+	// 访问分析，GeoIP数据库文件不存在时不统计国家
+	geoIP, err := analytics.OpenGeoIP(cfg.GeoIPDBPath)
+	if err != nil {
+		log.Printf("⚠️ Failed to open GeoIP database, country stats disabled: %v", err)
+	}
+	defer geoIP.Close()
+	collector := analytics.NewCollector(database.GetDB(), analytics.Options{
+		SiteURL:       cfg.SiteURL,
+		GeoIP:         geoIP,
+		FlushInterval: cfg.AnalyticsFlushInterval,
+		RetentionDays: cfg.AnalyticsRetentionDays,
+	})
+	
+	// 上传文件存储，多副本部署时使用对象存储
+	store, err := storage.New(cfg, cfg.StorageDriver)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+	
+	// 邮件发送，开发环境默认写入日志
+	// log和file会把重置密码链接落到本地，生产环境只允许smtp
+	if cfg.Environment == "production" && cfg.MailDriver != mailer.DriverSMTP {
+		log.Fatalf("MAIL_DRIVER=%q is not allowed in production, use %q", cfg.MailDriver, mailer.DriverSMTP)
+	}
+	mail, err := mailer.New(cfg, cfg.MailDriver)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer:", err)
+	}
+	
+	// 登录失败限制，计数存在数据库中，多副本共享
+	loginGuard := loginguard.New(database.GetDB(), loginguard.Options{
+		MaxFailures:      cfg.LoginMaxFailures,
+		MaxFailuresPerIP: cfg.LoginMaxFailuresPerIP,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		BackoffBase:      cfg.LoginBackoffBase,
+		FailureWindow:    cfg.LoginFailureWindow,
+	})
+	
 	// 创建API处理器
-	blogHandler := api.NewBlogHandler()
+	viewCounter := views.NewCounter(database.GetDB(), cfg.ViewDedupWindow, cfg.ViewFlushInterval)
+	blogHandler := api.NewBlogHandler(cfg, viewCounter)
+	revisionHandler := api.NewRevisionHandler(cfg)
+	previewHandler := api.NewPreviewHandler(cfg)
+	redirectHandler := api.NewRedirectHandler(cfg)
+	analyticsHandler := api.NewAnalyticsHandler(cfg, collector)
+	mediaHandler := api.NewMediaHandler(cfg, store)
+	searchHandler := api.NewSearchHandler(cfg)
+	commentHandler := api.NewCommentHandler(cfg)
+	categoryHandler := api.NewCategoryHandler(cfg)
+	tagHandler := api.NewTagHandler()
+	feedHandler := api.NewFeedHandler(cfg)
+	sitemapHandler := api.NewSitemapHandler(cfg)
 	contactHandler := api.NewContactHandler()
-	authHandler := api.NewAuthHandler(cfg)
+	authHandler := api.NewAuthHandler(cfg, loginGuard)
+	mfaHandler := api.NewMFAHandler(cfg, loginGuard)
+	settingsHandler := api.NewSettingsHandler(cfg)
+	lockoutHandler := api.NewLockoutHandler(cfg, loginGuard)
+	passwordResetHandler := api.NewPasswordResetHandler(cfg, mail, loginGuard)
 	sponsorHandler := handlers.NewSponsorHandler(database.GetDB())
 	
 	// API路由组
@@ -64,9 +130,26 @@ func main() {
 		api.GET("/posts", blogHandler.GetPosts)
 		api.GET("/posts/:id", blogHandler.GetPost)
 		
+		// 公开API - 分类
+		api.GET("/categories", categoryHandler.GetCategories)
+		
+		// 公开API - 标签云
+		api.GET("/tags", tagHandler.GetTags)
+		api.GET("/redirects/resolve", redirectHandler.Resolve)
+		
+		// 公开API - 评论
+		api.GET("/posts/:id/comments", commentHandler.GetComments)
+		api.POST("/posts/:id/comments", commentHandler.CreateComment)
+		
+		// 公开API - 全文搜索
+		api.GET("/search", searchHandler.Search)
+		
 		// 公开API - 联系表单
 		api.POST("/contact", contactHandler.SubmitContact)
 		
+		// 公开API - 访问统计上报
+		api.POST("/analytics/collect", analyticsHandler.Collect)
+		
 		// 赞助相关API
 		sponsor := api.Group("/sponsor")
 		{
@@ -81,6 +164,10 @@ func main() {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginMFA)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/forgot-password", passwordResetHandler.ForgotPassword)
+			auth.POST("/reset-password", passwordResetHandler.ResetPassword)
 			
 			// 需要认证的认证API
 			authRequired := auth.Group("/")
@@ -89,7 +176,17 @@ func main() {
 				authRequired.GET("/profile", authHandler.GetProfile)
 				authRequired.PUT("/profile", authHandler.UpdateProfile)
 				authRequired.POST("/change-password", authHandler.ChangePassword)
-				authRequired.POST("/refresh", authHandler.RefreshToken)
+				authRequired.POST("/logout", authHandler.Logout)
+				authRequired.POST("/logout-all", authHandler.LogoutAll)
+				authRequired.GET("/sessions", authHandler.GetSessions)
+				authRequired.DELETE("/sessions/:id", authHandler.RevokeSession)
+				
+				// 两步验证
+				authRequired.GET("/2fa", mfaHandler.GetStatus)
+				authRequired.POST("/2fa/setup", mfaHandler.Setup)
+				authRequired.POST("/2fa/activate", mfaHandler.Activate)
+				authRequired.POST("/2fa/disable", mfaHandler.Disable)
+				authRequired.POST("/2fa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
 			}
 		}
 		
@@ -99,9 +196,68 @@ func main() {
 		admin.Use(middleware.AdminMiddleware())
 		{
 			// 博客管理
+			admin.GET("/posts", blogHandler.GetAdminPosts)
 			admin.POST("/posts", blogHandler.CreatePost)
 			admin.PUT("/posts/:id", blogHandler.UpdatePost)
 			admin.DELETE("/posts/:id", blogHandler.DeletePost)
+			admin.GET("/posts/:id/views", blogHandler.GetPostViews)
+			
+			// 回收站
+			admin.GET("/posts/trash", blogHandler.GetTrash)
+			admin.POST("/posts/:id/restore", blogHandler.RestorePost)
+			admin.DELETE("/posts/:id/purge", blogHandler.PurgePost)
+			
+			// 文章版本
+			admin.GET("/posts/:id/revisions", revisionHandler.ListRevisions)
+			admin.GET("/posts/:id/revisions/compare", revisionHandler.CompareRevisions)
+			admin.GET("/posts/:id/revisions/:version", revisionHandler.GetRevision)
+			admin.POST("/posts/:id/revisions/:version/restore", revisionHandler.RestoreRevision)
+			
+			// 草稿预览链接
+			admin.GET("/posts/:id/previews", previewHandler.ListPreviews)
+			admin.POST("/posts/:id/previews", previewHandler.CreatePreview)
+			admin.DELETE("/posts/:id/previews/:previewId", previewHandler.RevokePreview)
+			
+			// 分类管理
+			admin.GET("/categories", categoryHandler.GetCategories)
+			admin.GET("/categories/:id", categoryHandler.GetCategory)
+			admin.POST("/categories", categoryHandler.CreateCategory)
+			admin.PUT("/categories/:id", categoryHandler.UpdateCategory)
+			admin.DELETE("/categories/:id", categoryHandler.DeleteCategory)
+			
+			// 评论审核
+			admin.GET("/comments", commentHandler.GetModerationQueue)
+			admin.PUT("/comments/:id/approve", commentHandler.ApproveComment)
+			admin.PUT("/comments/:id/reject", commentHandler.RejectComment)
+			admin.PUT("/comments/:id/spam", commentHandler.MarkCommentSpam)
+			admin.DELETE("/comments/:id", commentHandler.DeleteComment)
+			admin.POST("/comments/bulk", commentHandler.BulkModerate)
+			
+			// 媒体库
+			admin.GET("/media", mediaHandler.GetMediaList)
+			admin.POST("/media", mediaHandler.UploadMedia)
+			admin.GET("/media/:id", mediaHandler.GetMedia)
+			admin.PUT("/media/:id", mediaHandler.UpdateMedia)
+			admin.DELETE("/media/:id", mediaHandler.DeleteMedia)
+			
+			// 重定向管理
+			admin.GET("/redirects", redirectHandler.GetRedirects)
+			admin.POST("/redirects", redirectHandler.CreateRedirect)
+			admin.PUT("/redirects/:id", redirectHandler.UpdateRedirect)
+			admin.DELETE("/redirects/:id", redirectHandler.DeleteRedirect)
+			
+			// 安全设置
+			admin.GET("/settings/security", settingsHandler.GetSecuritySettings)
+			admin.PUT("/settings/security", settingsHandler.UpdateSecuritySettings)
+			admin.GET("/lockouts", lockoutHandler.GetLockouts)
+			admin.POST("/lockouts/unlock", lockoutHandler.Unlock)
+			
+			// 访问分析报表
+			admin.GET("/analytics/overview", analyticsHandler.GetOverview)
+			admin.GET("/analytics/top-posts", analyticsHandler.GetTopPosts)
+			admin.GET("/analytics/referrers", analyticsHandler.GetBreakdown(models.DimensionReferrer))
+			admin.GET("/analytics/countries", analyticsHandler.GetBreakdown(models.DimensionCountry))
+			admin.GET("/analytics/devices", analyticsHandler.GetBreakdown(models.DimensionDevice))
 			
 			// 联系消息管理
 			admin.GET("/messages", contactHandler.GetMessages)
@@ -112,8 +268,35 @@ func main() {
 		}
 	}
 	
-	// 静态文件服务（用于上传的文件）
-	r.Static("/uploads", cfg.UploadPath)
+	// 订阅源：RSS 2.0、Atom、JSON Feed，以及按标签、作者过滤的版本
+	r.GET("/feed.xml", feedHandler.RSS)
+	r.GET("/atom.xml", feedHandler.Atom)
+	r.GET("/feed.json", feedHandler.JSON)
+	r.GET("/tags/:slug/feed.xml", feedHandler.RSS)
+	r.GET("/tags/:slug/atom.xml", feedHandler.Atom)
+	r.GET("/tags/:slug/feed.json", feedHandler.JSON)
+	r.GET("/authors/:author/feed.xml", feedHandler.RSS)
+	r.GET("/authors/:author/atom.xml", feedHandler.Atom)
+	r.GET("/authors/:author/feed.json", feedHandler.JSON)
+	
+	// 站点地图与robots.txt
+	r.GET("/sitemap.xml", sitemapHandler.Sitemap)
+	r.GET("/sitemap.xml.gz", sitemapHandler.Sitemap)
+	r.GET("/sitemaps/:name", sitemapHandler.Chunk)
+	r.GET("/robots.txt", sitemapHandler.Robots)
+	
+	// 未匹配的路径查找重定向
+	r.NoRoute(redirectHandler.Fallback)
+	
+	// 上传的文件，?w=宽度返回缩略图
+	// 只有本地存储由应用直接提供文件，对象存储的地址跳转到公开地址或签名地址
+	if _, ok := store.(storage.FileSystem); ok {
+		r.GET("/uploads/*filepath", mediaHandler.ServeUpload)
+		r.HEAD("/uploads/*filepath", mediaHandler.ServeUpload)
+	} else {
+		r.GET("/uploads/*filepath", mediaHandler.RedirectUpload)
+		r.HEAD("/uploads/*filepath", mediaHandler.RedirectUpload)
+	}
 	
 	// API文档路由
 	r.GET("/", func(c *gin.Context) {
@@ -126,34 +309,135 @@ func main() {
 				"public": gin.H{
 					"GET /api/v1/health":        "健康检查",
 					"GET /api/v1/posts":         "获取博客文章列表",
-					"GET /api/v1/posts/:id":     "获取单个博客文章",
+					"GET /api/v1/posts/:id":     "获取单个博客文章（format=html|markdown，preview=预览令牌）",
+					"GET /api/v1/categories":    "获取分类列表及文章数",
+					"GET /api/v1/tags":          "获取标签云",
+					"GET /api/v1/redirects/resolve": "查询路径的重定向目标（?path=）",
+					"GET /api/v1/posts/:id/comments":  "获取文章评论",
+					"POST /api/v1/posts/:id/comments": "发表评论（需审核）",
+					"GET /api/v1/search":        "全文搜索文章",
 					"POST /api/v1/contact":      "提交联系消息",
+					"POST /api/v1/analytics/collect":   "上报页面访问和停留时间（不使用Cookie）",
 					"POST /api/v1/sponsor/create":      "创建赞助订单",
 					"GET /api/v1/sponsor/status/:orderId": "查询订单状态",
 					"GET /api/v1/sponsor/list":         "获取赞助者列表",
 					"GET /api/v1/sponsor/stats":        "获取赞助统计",
 				},
+				"feeds": gin.H{
+					"GET /feed.xml":                  "RSS 2.0订阅源",
+					"GET /atom.xml":                  "Atom订阅源",
+					"GET /feed.json":                 "JSON Feed订阅源",
+					"GET /tags/:slug/feed.xml":       "按标签订阅（也支持atom.xml、feed.json）",
+					"GET /authors/:author/feed.xml":  "按作者订阅（也支持atom.xml、feed.json）",
+				},
+				"uploads": gin.H{
+					"GET /uploads/*filepath":  "上传的文件，?w=320|640|1280返回缩略图（支持时返回WebP），使用对象存储时跳转到文件地址",
+				},
+				"seo": gin.H{
+					"GET /sitemap.xml":       "站点地图（URL较多时为索引）",
+					"GET /sitemap.xml.gz":    "站点地图gzip版本",
+					"GET /sitemaps/:name":    "分片站点地图，如1.xml、1.xml.gz",
+					"GET /robots.txt":        "robots.txt",
+				},
 				"auth": gin.H{
-					"POST /api/v1/auth/login":           "用户登录",
+					"POST /api/v1/auth/login":           "用户登录，启用两步验证时返回挑战令牌；连续失败会退避并锁定（429，带Retry-After）",
+					"POST /api/v1/auth/login/2fa":       "两步登录第二步，提交挑战令牌和验证码（或恢复码）",
 					"GET /api/v1/auth/profile":          "获取用户信息（需要认证）",
 					"PUT /api/v1/auth/profile":          "更新用户信息（需要认证）",
 					"POST /api/v1/auth/change-password": "修改密码（需要认证）",
-					"POST /api/v1/auth/refresh":         "刷新token（需要认证）",
+					"POST /api/v1/auth/refresh":         "用刷新令牌换取新的访问令牌，刷新令牌同时更换",
+					"POST /api/v1/auth/forgot-password": "找回密码，向账号邮箱发送重置链接（无论邮箱是否注册都返回成功）",
+					"POST /api/v1/auth/reset-password":  "用重置链接中的令牌设置新密码，并退出所有会话",
+					"POST /api/v1/auth/logout":          "退出当前会话（需要认证）",
+					"POST /api/v1/auth/logout-all":      "退出全部会话（需要认证）",
+					"GET /api/v1/auth/sessions":         "登录会话列表，含登录时间、最后活跃时间、设备和网段（需要认证）",
+					"DELETE /api/v1/auth/sessions/:id":  "注销指定会话（需要认证）",
+					"GET /api/v1/auth/2fa":              "两步验证状态（需要认证）",
+					"POST /api/v1/auth/2fa/setup":       "生成TOTP密钥，返回otpauth地址和二维码（需要认证）",
+					"POST /api/v1/auth/2fa/activate":    "提交验证码启用两步验证，返回恢复码（需要认证）",
+					"POST /api/v1/auth/2fa/disable":     "关闭两步验证，需要密码和验证码（需要认证）",
+					"POST /api/v1/auth/2fa/recovery-codes": "重新生成恢复码（需要认证）",
 				},
 				"admin": gin.H{
+					"GET /api/v1/admin/posts":                 "全部文章列表，status=draft|scheduled|published|trashed（需要管理员权限）",
 					"POST /api/v1/admin/posts":                "创建博客文章（需要管理员权限）",
 					"PUT /api/v1/admin/posts/:id":             "更新博客文章（需要管理员权限）",
-					"DELETE /api/v1/admin/posts/:id":          "删除博客文章（需要管理员权限）",
+					"DELETE /api/v1/admin/posts/:id":          "将博客文章移入回收站（需要管理员权限）",
+					"GET /api/v1/admin/posts/trash":           "回收站文章列表（需要管理员权限）",
+					"POST /api/v1/admin/posts/:id/restore":    "从回收站恢复文章（需要管理员权限）",
+					"GET /api/v1/admin/posts/:id/views":       "文章每日浏览量，?days=30（需要管理员权限）",
+					"DELETE /api/v1/admin/posts/:id/purge":    "永久删除回收站中的文章（需要管理员权限）",
+					"GET /api/v1/admin/posts/:id/revisions":   "文章版本列表（需要管理员权限）",
+					"GET /api/v1/admin/posts/:id/revisions/compare": "比较两个版本，?from=&to=（需要管理员权限）",
+					"GET /api/v1/admin/posts/:id/revisions/:version": "获取指定版本（需要管理员权限）",
+					"POST /api/v1/admin/posts/:id/revisions/:version/restore": "恢复到指定版本（需要管理员权限）",
+					"GET /api/v1/admin/posts/:id/previews":    "有效的预览链接（需要管理员权限）",
+					"POST /api/v1/admin/posts/:id/previews":   "生成草稿预览链接（需要管理员权限）",
+					"DELETE /api/v1/admin/posts/:id/previews/:previewId": "撤销预览链接（需要管理员权限）",
+					"GET /api/v1/admin/categories":            "获取分类列表（需要管理员权限）",
+					"POST /api/v1/admin/categories":           "创建分类（需要管理员权限）",
+					"PUT /api/v1/admin/categories/:id":        "更新分类（需要管理员权限）",
+					"DELETE /api/v1/admin/categories/:id":     "删除分类（需要管理员权限）",
+					"GET /api/v1/admin/comments":              "评论审核队列（需要管理员权限）",
+					"PUT /api/v1/admin/comments/:id/approve":  "通过评论（需要管理员权限）",
+					"PUT /api/v1/admin/comments/:id/reject":   "拒绝评论（需要管理员权限）",
+					"PUT /api/v1/admin/comments/:id/spam":     "标记垃圾评论（需要管理员权限）",
+					"DELETE /api/v1/admin/comments/:id":       "删除评论及回复（需要管理员权限）",
+					"POST /api/v1/admin/comments/bulk":        "批量审核评论（需要管理员权限）",
+					"GET /api/v1/admin/analytics/overview":    "每日访问量、独立访客和平均停留时间，?from=&to=（需要管理员权限）",
+					"GET /api/v1/admin/analytics/top-posts":   "热门文章及平均停留时间（需要管理员权限）",
+					"GET /api/v1/admin/analytics/referrers":   "来源域名排行（需要管理员权限）",
+					"GET /api/v1/admin/analytics/countries":   "访客国家分布（需要管理员权限）",
+					"GET /api/v1/admin/analytics/devices":     "设备类型分布（需要管理员权限）",
 					"GET /api/v1/admin/messages":              "获取联系消息列表（需要管理员权限）",
 					"GET /api/v1/admin/messages/:id":          "获取单个联系消息（需要管理员权限）",
 					"PUT /api/v1/admin/messages/:id/read":     "标记消息为已读（需要管理员权限）",
 					"PUT /api/v1/admin/messages/:id/replied":  "标记消息为已回复（需要管理员权限）",
 					"DELETE /api/v1/admin/messages/:id":       "删除联系消息（需要管理员权限）",
+					"GET /api/v1/admin/media":                 "媒体库列表，?search=（需要管理员权限）",
+					"POST /api/v1/admin/media":                "上传图片，multipart字段file、alt（需要管理员权限）",
+					"GET /api/v1/admin/media/:id":             "获取媒体及引用它的文章（需要管理员权限）",
+					"PUT /api/v1/admin/media/:id":             "修改替代文本（需要管理员权限）",
+					"DELETE /api/v1/admin/media/:id":          "删除未被引用的媒体（需要管理员权限）",
+					"GET /api/v1/admin/redirects":             "重定向列表及命中次数（需要管理员权限）",
+					"POST /api/v1/admin/redirects":            "创建重定向（需要管理员权限）",
+					"PUT /api/v1/admin/redirects/:id":         "更新重定向（需要管理员权限）",
+					"DELETE /api/v1/admin/redirects/:id":      "删除重定向（需要管理员权限）",
+					"GET /api/v1/admin/settings/security":     "安全设置（需要管理员权限）",
+					"PUT /api/v1/admin/settings/security":     "修改安全设置，如要求管理员启用两步验证（需要管理员权限）",
+					"GET /api/v1/admin/lockouts":              "登录锁定记录，active=true只看锁定中的（需要管理员权限）",
+					"POST /api/v1/admin/lockouts/unlock":      "按用户名或IP解除登录锁定（需要管理员权限）",
 				},
 			},
 		})
 	})
 	
+	// 启动定时发布、回收站、会话和登录失败计数清理、浏览量和访问统计写入任务，关闭服务时一并停止
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){
+		scheduler.NewPublisher(database.GetDB(), cfg.PublishCheckInterval).Run,
+		scheduler.NewTrashPurger(database.GetDB(), cfg.TrashRetention, time.Hour).Run,
+		// 已过期或撤销的会话保留7天，便于排查令牌重复使用
+		scheduler.NewSessionPurger(database.GetDB(), 7*24*time.Hour, time.Hour).Run,
+		loginGuard.Run,
+		viewCounter.Run,
+		collector.Run,
+	} {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(workerCtx)
+		}(run)
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		// 服务器已停止接收请求，等待还在发送的重置密码邮件
+		passwordResetHandler.Wait()
+		close(workersDone)
+	}()
+	
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort),
@@ -191,6 +475,14 @@ func main() {
 		log.Fatal("❌ Server forced to shutdown:", err)
 	}
 	
+	// 停止后台任务，等待正在进行的事务和邮件发送结束
+	stopWorkers()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		log.Println("⚠️ Background workers did not stop in time")
+	}
+	
 	log.Println("✅ Server exited")
 }
 
@@ -0,0 +1,69 @@
+/*
+开发心理过程：
+1. 部署时预先生成站点地图，由静态服务器直接提供，减轻API压力
+2. 与接口共用sitemap包，输出结果完全一致
+3. 按配置同时输出gzip版本和robots.txt
+*/
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/sitemap"
+)
+
+func main() {
+	outDir := flag.String("out", "./dist", "站点地图输出目录")
+	withRobots := flag.Bool("robots", true, "同时生成robots.txt")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	// 只读取数据，不执行迁移；表结构由API服务启动时负责
+	if err := database.Connect(cfg); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	urls, err := sitemap.Collect(database.GetDB(), cfg.SiteURL)
+	if err != nil {
+		log.Fatal("Failed to collect sitemap URLs:", err)
+	}
+
+	files, err := sitemap.Files(urls, cfg.SitemapChunkSize, cfg.SiteURL)
+	if err != nil {
+		log.Fatal("Failed to render sitemap:", err)
+	}
+
+	for name, body := range files {
+		writeFile(filepath.Join(*outDir, name), body)
+
+		if cfg.SitemapGzip {
+			compressed, err := sitemap.Gzip(body)
+			if err != nil {
+				log.Fatal("Failed to compress sitemap:", err)
+			}
+			writeFile(filepath.Join(*outDir, name+".gz"), compressed)
+		}
+	}
+
+	if *withRobots {
+		writeFile(filepath.Join(*outDir, "robots.txt"), []byte(sitemap.Robots(cfg.SiteURL, cfg.RobotsDisallow)))
+	}
+
+	log.Printf("✅ Sitemap generated: %d URLs, %d files in %s", len(urls), len(files), *outDir)
+}
+
+func writeFile(path string, body []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Fatal("Failed to create directory:", err)
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		log.Fatal("Failed to write file:", err)
+	}
+	log.Printf("📄 %s", path)
+}
@@ -0,0 +1,108 @@
+/*
+开发心理过程：
+1. 切换存储后端前把已有文件原样复制过去，对象键不变，数据库中的记录不需要修改
+2. 目标中已有同样大小的对象时跳过，中途失败可以直接重新执行
+3. 缩略图可以按需重新生成，默认不复制，需要时用-variants带上
+4. 两端的连接参数都来自同一份配置，只用-from和-to选择驱动
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"mime"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/storage"
+)
+
+func main() {
+	from := flag.String("from", storage.DriverLocal, "源存储驱动（local或s3）")
+	to := flag.String("to", storage.DriverS3, "目标存储驱动（local或s3）")
+	withVariants := flag.Bool("variants", false, "同时复制缩略图缓存")
+	overwrite := flag.Bool("overwrite", false, "覆盖目标中已存在的对象")
+	dryRun := flag.Bool("dry-run", false, "只列出需要复制的文件")
+	flag.Parse()
+
+	if *from == *to {
+		log.Fatal("Source and destination storage must differ")
+	}
+
+	cfg := config.LoadConfig()
+	src, err := storage.New(cfg, *from)
+	if err != nil {
+		log.Fatal("Failed to open source storage:", err)
+	}
+	dst, err := storage.New(cfg, *to)
+	if err != nil {
+		log.Fatal("Failed to open destination storage:", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var copied, skipped, failed int
+	err = src.Walk(ctx, "", func(obj storage.Object) error {
+		if !*withVariants && strings.HasPrefix(obj.Key, ".variants/") {
+			return nil
+		}
+
+		if !*overwrite {
+			existing, err := dst.Stat(ctx, obj.Key)
+			if err == nil && existing.Size == obj.Size {
+				skipped++
+				return nil
+			}
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+		}
+
+		if *dryRun {
+			log.Printf("📄 %s (%d bytes)", obj.Key, obj.Size)
+			copied++
+			return nil
+		}
+		if err := copyObject(ctx, src, dst, obj); err != nil {
+			log.Printf("❌ %s: %v", obj.Key, err)
+			failed++
+			return nil
+		}
+		log.Printf("📄 %s", obj.Key)
+		copied++
+		return nil
+	})
+	if err != nil {
+		log.Fatal("Failed to migrate storage:", err)
+	}
+
+	log.Printf("✅ Storage migrated from %s to %s: %d copied, %d skipped, %d failed", *from, *to, copied, skipped, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func copyObject(ctx context.Context, src, dst storage.Storage, obj storage.Object) error {
+	r, err := src.Open(ctx, obj.Key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// 列举对象存储时不返回类型，按扩展名推断
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(obj.Key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return dst.Put(ctx, obj.Key, r, obj.Size, contentType)
+}
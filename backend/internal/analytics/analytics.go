@@ -0,0 +1,430 @@
+/*
+开发心理过程：
+1. 前端通过sendBeacon上报页面路径、来源和停留时间，不使用Cookie
+2. 独立访客用加盐的IP+UA摘要在内存中判断，盐每天轮换且不落盘，
+   数据库里只有按天聚合的计数，没有IP、UA或任何访客标识
+3. 国家在收到请求时用本地GeoIP数据库换算，IP用完即丢；数据库文件不存在时不统计国家
+4. 计数先在内存中聚合，定时以 x = x + n 的方式批量写入，与浏览量计数器相同
+5. 所有数据按天聚合，每天的路径和来源域名数量有上限，超出的归入(other)，
+   再配合保留天数定期删除旧数据，存储量有上界
+6. 多副本部署时每个实例各自判断独立访客，同一访客落在不同实例上会被重复计算
+*/
+
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/views"
+)
+
+const (
+	// maxVisitorKeys 独立访客判断表的最大条目数，超过后清空，独立访客数可能偏高
+	maxVisitorKeys = 200000
+	// maxPathsPerDay 每天记录的不同路径数上限
+	maxPathsPerDay = 2000
+	// maxReferrersPerDay 每天记录的不同来源域名数上限
+	maxReferrersPerDay = 1000
+	// maxDuration 单次停留时间上限，超过的按上限计，避免挂机页面拉高平均值
+	maxDuration = 30 * 60
+
+	// OtherValue 超出上限的路径或来源域名归入此项
+	OtherValue = "(other)"
+	// DirectValue 没有来源的访问
+	DirectValue = "(direct)"
+)
+
+// Options 统计收集器配置
+type Options struct {
+	SiteURL       string        // 站点地址，来自本站的来源不计入来源统计
+	GeoIP         *GeoIP        // 为nil时不统计国家
+	FlushInterval time.Duration // 写库间隔
+	RetentionDays int           // 聚合数据保留天数，0表示一直保留
+}
+
+type pageKey struct {
+	day  string
+	path string
+}
+
+type breakdownKey struct {
+	day       string
+	dimension string
+	value     string
+}
+
+type counts struct {
+	views      int64
+	uniques    int64
+	seconds    int64
+	timedViews int64
+}
+
+// batch 等待写入数据库的增量
+type batch struct {
+	site      map[string]*counts
+	pages     map[pageKey]*counts
+	breakdown map[breakdownKey]*counts
+}
+
+func newBatch() *batch {
+	return &batch{
+		site:      make(map[string]*counts),
+		pages:     make(map[pageKey]*counts),
+		breakdown: make(map[breakdownKey]*counts),
+	}
+}
+
+func (b *batch) empty() bool {
+	return len(b.site) == 0 && len(b.pages) == 0 && len(b.breakdown) == 0
+}
+
+// merge 把写库失败的增量放回
+func (b *batch) merge(other *batch) {
+	for k, c := range other.site {
+		add(b.site, k, c)
+	}
+	for k, c := range other.pages {
+		add(b.pages, k, c)
+	}
+	for k, c := range other.breakdown {
+		add(b.breakdown, k, c)
+	}
+}
+
+func add[K comparable](m map[K]*counts, key K, c *counts) {
+	target := get(m, key)
+	target.views += c.views
+	target.uniques += c.uniques
+	target.seconds += c.seconds
+	target.timedViews += c.timedViews
+}
+
+func get[K comparable](m map[K]*counts, key K) *counts {
+	c, ok := m[key]
+	if !ok {
+		c = &counts{}
+		m[key] = c
+	}
+	return c
+}
+
+// Collector 访问统计收集器
+type Collector struct {
+	db       *gorm.DB
+	opts     Options
+	siteHost string
+
+	mu        sync.Mutex
+	pending   *batch
+	day       string
+	salt      []byte
+	seen      map[string]struct{}
+	paths     map[string]struct{}
+	referrers map[string]struct{}
+	lastPurge time.Time
+}
+
+// NewCollector 创建统计收集器
+func NewCollector(db *gorm.DB, opts Options) *Collector {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 30 * time.Second
+	}
+	return &Collector{
+		db:       db,
+		opts:     opts,
+		siteHost: ReferrerDomain(opts.SiteURL),
+		pending:  newBatch(),
+	}
+}
+
+// Record 记录一次上报，爬虫和无法识别的路径直接忽略，返回是否计入
+func (c *Collector) Record(event models.AnalyticsEvent, ip, userAgent string) bool {
+	if views.IsBot(userAgent) {
+		return false
+	}
+	path := NormalizePath(event.Path)
+	if path == "" {
+		return false
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rotateLocked(now)
+	path = c.capLocked(c.paths, path, maxPathsPerDay)
+
+	if event.Type == "leave" {
+		duration := event.Duration
+		if duration <= 0 {
+			return false
+		}
+		if duration > maxDuration {
+			duration = maxDuration
+		}
+		page := get(c.pending.pages, pageKey{c.day, path})
+		page.seconds += int64(duration)
+		page.timedViews++
+		return true
+	}
+
+	visitor := c.visitorLocked(ip, userAgent)
+
+	site := get(c.pending.site, c.day)
+	site.views++
+	if c.firstLocked(visitor, "") {
+		site.uniques++
+	}
+
+	page := get(c.pending.pages, pageKey{c.day, path})
+	page.views++
+	if c.firstLocked(visitor, "p\x00"+path) {
+		page.uniques++
+	}
+
+	// 站内跳转不算来源
+	switch domain := ReferrerDomain(event.Referrer); {
+	case domain == "":
+		c.countLocked(visitor, models.DimensionReferrer, DirectValue)
+	case domain != c.siteHost:
+		c.countLocked(visitor, models.DimensionReferrer, c.capLocked(c.referrers, domain, maxReferrersPerDay))
+	}
+	if country := c.opts.GeoIP.Country(ip); country != "" {
+		c.countLocked(visitor, models.DimensionCountry, country)
+	}
+	c.countLocked(visitor, models.DimensionDevice, DeviceClass(userAgent))
+	return true
+}
+
+// Run 按间隔写入数据库并清理过期数据，ctx取消后写入剩余计数再返回
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 服务关闭时ctx已取消，使用新的上下文写入最后一批
+			if err := c.Flush(context.Background()); err != nil {
+				log.Printf("Failed to flush analytics: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to flush analytics: %v", err)
+			}
+			if err := c.purgeDaily(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to purge old analytics: %v", err)
+			}
+		}
+	}
+}
+
+// Flush 将内存中的聚合写入数据库，失败时放回下次重试
+func (c *Collector) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	b := c.pending
+	c.pending = newBatch()
+	c.mu.Unlock()
+
+	if b.empty() {
+		return nil
+	}
+
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return writeBatch(tx, b)
+	})
+	if err != nil {
+		c.mu.Lock()
+		c.pending.merge(b)
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Purge 删除指定日期之前的聚合数据
+func (c *Collector) Purge(ctx context.Context, before time.Time) error {
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{
+			&models.AnalyticsSiteDay{},
+			&models.AnalyticsPageDay{},
+			&models.AnalyticsBreakdownDay{},
+		} {
+			if err := tx.Where("day < ?", before).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// purgeDaily 每天最多清理一次过期数据
+func (c *Collector) purgeDaily(ctx context.Context) error {
+	if c.opts.RetentionDays <= 0 || time.Since(c.lastPurge) < 24*time.Hour {
+		return nil
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if err := c.Purge(ctx, today.AddDate(0, 0, -c.opts.RetentionDays)); err != nil {
+		return err
+	}
+	c.lastPurge = time.Now()
+	return nil
+}
+
+// writeBatch 按主键顺序累加写入，多个实例同时写入时不会互相死锁
+func writeBatch(tx *gorm.DB, b *batch) error {
+	if len(b.site) > 0 {
+		rows := make([]models.AnalyticsSiteDay, 0, len(b.site))
+		for day, n := range b.site {
+			rows = append(rows, models.AnalyticsSiteDay{Day: parseDay(day), Views: n.views, Uniques: n.uniques})
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Day.Before(rows[j].Day) })
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "day"}},
+			DoUpdates: accumulate("analytics_site_days", "views", "uniques"),
+		}).Create(&rows).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(b.pages) > 0 {
+		rows := make([]models.AnalyticsPageDay, 0, len(b.pages))
+		for key, n := range b.pages {
+			rows = append(rows, models.AnalyticsPageDay{
+				Day:        parseDay(key.day),
+				Path:       key.path,
+				Views:      n.views,
+				Uniques:    n.uniques,
+				Seconds:    n.seconds,
+				TimedViews: n.timedViews,
+			})
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if !rows[i].Day.Equal(rows[j].Day) {
+				return rows[i].Day.Before(rows[j].Day)
+			}
+			return rows[i].Path < rows[j].Path
+		})
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "day"}, {Name: "path"}},
+			DoUpdates: accumulate("analytics_page_days", "views", "uniques", "seconds", "timed_views"),
+		}).CreateInBatches(&rows, 500).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(b.breakdown) > 0 {
+		rows := make([]models.AnalyticsBreakdownDay, 0, len(b.breakdown))
+		for key, n := range b.breakdown {
+			rows = append(rows, models.AnalyticsBreakdownDay{
+				Day:       parseDay(key.day),
+				Dimension: key.dimension,
+				Value:     key.value,
+				Views:     n.views,
+				Uniques:   n.uniques,
+			})
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			a, b := rows[i], rows[j]
+			if !a.Day.Equal(b.Day) {
+				return a.Day.Before(b.Day)
+			}
+			if a.Dimension != b.Dimension {
+				return a.Dimension < b.Dimension
+			}
+			return a.Value < b.Value
+		})
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "day"}, {Name: "dimension"}, {Name: "value"}},
+			DoUpdates: accumulate("analytics_breakdown_days", "views", "uniques"),
+		}).CreateInBatches(&rows, 500).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accumulate 冲突时在原值上累加
+func accumulate(table string, columns ...string) clause.Set {
+	values := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		values[column] = gorm.Expr(table + "." + column + " + EXCLUDED." + column)
+	}
+	return clause.Assignments(values)
+}
+
+func parseDay(day string) time.Time {
+	t, _ := time.Parse("2006-01-02", day)
+	return t
+}
+
+// rotateLocked 跨天时换盐并清空当天的访客、路径和来源记录
+func (c *Collector) rotateLocked(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if day == c.day {
+		return
+	}
+	c.day = day
+	c.salt = make([]byte, 32)
+	if _, err := rand.Read(c.salt); err != nil {
+		log.Printf("Failed to generate analytics salt: %v", err)
+	}
+	c.seen = make(map[string]struct{})
+	c.paths = make(map[string]struct{})
+	c.referrers = make(map[string]struct{})
+}
+
+// visitorLocked 生成当天的访客摘要
+func (c *Collector) visitorLocked(ip, userAgent string) string {
+	h := sha256.New()
+	h.Write(c.salt)
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	return string(h.Sum(nil)[:16])
+}
+
+// firstLocked 判断访客今天是否第一次出现在scope中
+func (c *Collector) firstLocked(visitor, scope string) bool {
+	key := visitor + scope
+	if _, ok := c.seen[key]; ok {
+		return false
+	}
+	if len(c.seen) >= maxVisitorKeys {
+		c.seen = make(map[string]struct{})
+	}
+	c.seen[key] = struct{}{}
+	return true
+}
+
+// countLocked 累加细分维度的访问量和独立访客数
+func (c *Collector) countLocked(visitor, dimension, value string) {
+	entry := get(c.pending.breakdown, breakdownKey{c.day, dimension, value})
+	entry.views++
+	if c.firstLocked(visitor, strings.Join([]string{"d", dimension, value}, "\x00")) {
+		entry.uniques++
+	}
+}
+
+// capLocked 当天的不同取值达到上限后，新出现的取值归入(other)
+func (c *Collector) capLocked(known map[string]struct{}, value string, limit int) string {
+	if _, ok := known[value]; ok {
+		return value
+	}
+	if len(known) >= limit {
+		return OtherValue
+	}
+	known[value] = struct{}{}
+	return value
+}
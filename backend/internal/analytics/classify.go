@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+)
+
+// 页面路径和来源域名的最大长度，与数据表字段一致
+const (
+	maxPathLength   = 300
+	maxDomainLength = 255
+)
+
+var (
+	tabletPattern  = regexp.MustCompile(`(?i)ipad|tablet|kindle|silk|playbook`)
+	mobilePattern  = regexp.MustCompile(`(?i)mobi|iphone|ipod|windows phone|blackberry|opera mini`)
+	androidPattern = regexp.MustCompile(`(?i)android`)
+)
+
+// DeviceClass 根据User-Agent粗略判断设备类型，不带Mobile标记的Android设备视为平板
+func DeviceClass(userAgent string) string {
+	switch {
+	case tabletPattern.MatchString(userAgent):
+		return DeviceTablet
+	case mobilePattern.MatchString(userAgent):
+		return DeviceMobile
+	case androidPattern.MatchString(userAgent):
+		return DeviceTablet
+	default:
+		return DeviceDesktop
+	}
+}
+
+// NormalizePath 只保留路径部分，去掉查询参数、锚点和末尾斜杠；不是站内相对路径时返回空字符串
+func NormalizePath(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return ""
+	}
+	p := u.Path
+	if len(p) > 1 {
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		p = "/"
+	}
+	return truncate(p, maxPathLength)
+}
+
+// ReferrerDomain 提取来源的域名并去掉www.前缀；无法解析时返回空字符串
+func ReferrerDomain(referrer string) string {
+	u, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		return truncate(host, maxDomainLength)
+	}
+	return truncate(strings.TrimPrefix(host, "www."), maxDomainLength)
+}
+
+// truncate 按字符截断，不切断多字节字符
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n])
+}
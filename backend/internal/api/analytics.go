@@ -0,0 +1,324 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"techblog-api/backend/internal/analytics"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+// maxBeaconSize 上报请求体的最大字节数
+const maxBeaconSize = 4 << 10
+
+// maxAnalyticsRange 报表最多查询的天数
+const maxAnalyticsRange = 366
+
+type AnalyticsHandler struct {
+	config    *config.Config
+	collector *analytics.Collector
+}
+
+func NewAnalyticsHandler(cfg *config.Config, collector *analytics.Collector) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		config:    cfg,
+		collector: collector,
+	}
+}
+
+// Collect 接收前端上报的访问事件，兼容sendBeacon发送的text/plain请求体
+func (h *AnalyticsHandler) Collect(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBeaconSize)
+
+	var event models.AnalyticsEvent
+	if err := c.ShouldBindWith(&event, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid analytics event",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 尊重浏览器的“请勿跟踪”设置
+	if c.GetHeader("DNT") != "1" && c.GetHeader("Sec-GPC") != "1" {
+		h.collector.Record(event, c.ClientIP(), c.Request.UserAgent())
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusNoContent)
+}
+
+// GetOverview 日期范围内每天的访问量、独立访客数和平均停留时间（需要管理员权限）
+func (h *AnalyticsHandler) GetOverview(c *gin.Context) {
+	from, to, _, ok := bindAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	var rows []models.AnalyticsSiteDay
+	if err := db.Where("day BETWEEN ? AND ?", from, to).Order("day ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch analytics",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var timing struct {
+		Seconds    int64
+		TimedViews int64
+	}
+	if err := db.Model(&models.AnalyticsPageDay{}).
+		Select("COALESCE(SUM(seconds), 0) AS seconds, COALESCE(SUM(timed_views), 0) AS timed_views").
+		Where("day BETWEEN ? AND ?", from, to).
+		Scan(&timing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch analytics",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	byDay := make(map[string]models.AnalyticsSiteDay, len(rows))
+	for _, row := range rows {
+		byDay[row.Day.UTC().Format("2006-01-02")] = row
+	}
+
+	overview := models.AnalyticsOverview{
+		From:          from.Format("2006-01-02"),
+		To:            to.Format("2006-01-02"),
+		AvgTimeOnPage: averageSeconds(timing.Seconds, timing.TimedViews),
+		Daily:         []models.DailyUniques{},
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		row := byDay[key]
+		overview.Views += row.Views
+		overview.Uniques += row.Uniques
+		overview.Daily = append(overview.Daily, models.DailyUniques{Day: key, Views: row.Views, Uniques: row.Uniques})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Analytics overview fetched successfully",
+		Data:    overview,
+	})
+}
+
+// GetTopPosts 日期范围内访问最多的文章及平均停留时间，旧slug的访问计入当前文章（需要管理员权限）
+func (h *AnalyticsHandler) GetTopPosts(c *gin.Context) {
+	from, to, limit, ok := bindAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	var pages []struct {
+		Path       string
+		Views      int64
+		Uniques    int64
+		Seconds    int64
+		TimedViews int64
+	}
+	if err := db.Model(&models.AnalyticsPageDay{}).
+		Select("path, SUM(views) AS views, SUM(uniques) AS uniques, SUM(seconds) AS seconds, SUM(timed_views) AS timed_views").
+		Where("day BETWEEN ? AND ? AND path LIKE ?", from, to, "/blog/%").
+		Group("path").
+		Scan(&pages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch top posts",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	slugs := make([]string, 0, len(pages))
+	for _, page := range pages {
+		slugs = append(slugs, strings.TrimPrefix(page.Path, "/blog/"))
+	}
+
+	// 当前slug和旧slug都映射到文章
+	var posts []models.BlogPost
+	var redirects []models.SlugRedirect
+	if len(slugs) > 0 {
+		if err := db.Select("id, title, slug").Where("slug IN ?", slugs).Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch top posts",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if err := db.Where("slug IN ?", slugs).Find(&redirects).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch top posts",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	postBySlug := make(map[string]uint, len(posts)+len(redirects))
+	titles := make(map[uint]models.BlogPost, len(posts))
+	for _, post := range posts {
+		postBySlug[post.Slug] = post.ID
+		titles[post.ID] = post
+	}
+	for _, redirect := range redirects {
+		if _, ok := postBySlug[redirect.Slug]; !ok {
+			postBySlug[redirect.Slug] = redirect.PostID
+		}
+	}
+
+	type totals struct {
+		views, uniques, seconds, timedViews int64
+	}
+	byPost := make(map[uint]*totals)
+	for _, page := range pages {
+		postID, ok := postBySlug[strings.TrimPrefix(page.Path, "/blog/")]
+		if !ok {
+			continue
+		}
+		t, ok := byPost[postID]
+		if !ok {
+			t = &totals{}
+			byPost[postID] = t
+		}
+		t.views += page.Views
+		t.uniques += page.Uniques
+		t.seconds += page.Seconds
+		t.timedViews += page.TimedViews
+	}
+
+	// 旧slug对应的文章不在当前slug查询结果中时补查标题
+	var missing []uint
+	for postID := range byPost {
+		if _, ok := titles[postID]; !ok {
+			missing = append(missing, postID)
+		}
+	}
+	if len(missing) > 0 {
+		var extra []models.BlogPost
+		db.Select("id, title, slug").Find(&extra, missing)
+		for _, post := range extra {
+			titles[post.ID] = post
+		}
+	}
+
+	result := make([]models.TopPage, 0, len(byPost))
+	for postID, t := range byPost {
+		post, ok := titles[postID]
+		if !ok {
+			continue // 文章已删除
+		}
+		id := postID
+		result = append(result, models.TopPage{
+			Path:          "/blog/" + post.Slug,
+			PostID:        &id,
+			Title:         post.Title,
+			Views:         t.views,
+			Uniques:       t.uniques,
+			AvgTimeOnPage: averageSeconds(t.seconds, t.timedViews),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Views != result[j].Views {
+			return result[i].Views > result[j].Views
+		}
+		return *result[i].PostID < *result[j].PostID
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Top posts fetched successfully",
+		Data:    result,
+	})
+}
+
+// GetBreakdown 按来源域名、国家或设备类型汇总日期范围内的访问量（需要管理员权限）
+func (h *AnalyticsHandler) GetBreakdown(dimension string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, limit, ok := bindAnalyticsRange(c)
+		if !ok {
+			return
+		}
+
+		db := database.GetDB()
+		items := []models.BreakdownItem{}
+		if err := db.Model(&models.AnalyticsBreakdownDay{}).
+			Select("value, SUM(views) AS views, SUM(uniques) AS uniques").
+			Where("dimension = ? AND day BETWEEN ? AND ?", dimension, from, to).
+			Group("value").
+			Order("views DESC, value ASC").
+			Limit(limit).
+			Scan(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch analytics",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Analytics fetched successfully",
+			Data:    items,
+		})
+	}
+}
+
+// bindAnalyticsRange 解析报表的日期范围，默认最近30天；失败时已写入响应
+func bindAnalyticsRange(c *gin.Context) (from, to time.Time, limit int, ok bool) {
+	var query models.AnalyticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return from, to, 0, false
+	}
+
+	to = time.Now().UTC().Truncate(24 * time.Hour)
+	if query.To != "" {
+		to, _ = time.Parse("2006-01-02", query.To)
+	}
+	from = to.AddDate(0, 0, -29)
+	if query.From != "" {
+		from, _ = time.Parse("2006-01-02", query.From)
+	}
+
+	if from.After(to) || to.Sub(from) >= maxAnalyticsRange*24*time.Hour {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "from must not be after to, and the range is limited to 366 days",
+			Error:   "invalid_range",
+		})
+		return from, to, 0, false
+	}
+	return from, to, query.Limit, true
+}
+
+// averageSeconds 平均停留秒数，保留一位小数
+func averageSeconds(seconds, timedViews int64) float64 {
+	if timedViews == 0 {
+		return 0
+	}
+	return float64(seconds*10/timedViews) / 10
+}
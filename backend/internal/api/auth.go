@@ -1,23 +1,35 @@
 package api
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 	"techblog-api/backend/internal/config"
 	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/loginguard"
 	"techblog-api/backend/internal/middleware"
 	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/session"
 )
 
+// dummyPasswordHash 用户不存在时也比较一次密码，响应时间不暴露用户名是否存在
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("techblog-dummy-password"), bcrypt.DefaultCost)
+
 type AuthHandler struct {
 	config *config.Config
+	guard  *loginguard.Guard
 }
 
-func NewAuthHandler(cfg *config.Config) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, guard *loginguard.Guard) *AuthHandler {
 	return &AuthHandler{
 		config: cfg,
+		guard:  guard,
 	}
 }
 
@@ -33,11 +45,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 	
+	// 用户名或IP失败次数过多时先等待
+	if !checkThrottle(c, h.guard, req.Username) {
+		return
+	}
+	
 	db := database.GetDB()
 	var user models.User
 	
-	// 查找用户
-	if err := db.Where("username = ? AND active = ?", req.Username, true).First(&user).Error; err != nil {
+	// 查找用户；用户不存在时用虚拟哈希比较密码，两种情况耗时相同
+	found := db.Where("username = ? AND active = ?", req.Username, true).First(&user).Error == nil
+	passwordHash := dummyPasswordHash
+	if found {
+		passwordHash = []byte(user.Password)
+	}
+	
+	// 验证密码
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(req.Password)); err != nil || !found {
+		recordFailure(c, h.guard, req.Username)
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
 			Message: "Invalid username or password",
@@ -46,18 +71,164 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 	
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	// 启用了两步验证时先返回挑战令牌，提交验证码后才签发访问令牌
+	// 密码正确只结束这次尝试，用户名的失败计数等验证码通过后才清除
+	if user.TOTPEnabled {
+		releaseAttempt(c, h.guard, req.Username)
+		challenge, err := middleware.GenerateMFAChallenge(&user, h.config)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to generate token",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Two-factor authentication required",
+			Data: models.MFAChallenge{
+				MFARequired:    true,
+				ChallengeToken: challenge,
+				ExpiresIn:      int(h.config.MFAChallengeTTL.Seconds()),
+			},
+		})
+		return
+	}
+	
+	recordSuccess(c, h.guard, req.Username)
+	h.completeLogin(c, &user)
+}
+
+// LoginMFA 两步登录的第二步，用挑战令牌和验证码（或恢复码）换取访问令牌
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+	
+	claims, err := middleware.ParseMFAChallenge(req.ChallengeToken, h.config)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
-			Message: "Invalid username or password",
-			Error:   "invalid_credentials",
+			Message: "Login challenge is invalid or expired, please sign in again",
+			Error:   "invalid_challenge",
+		})
+		return
+	}
+	
+	db := database.GetDB()
+	var user models.User
+	if err := db.Where("active = ? AND totp_enabled = ?", true, true).First(&user, claims.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Login challenge is invalid or expired, please sign in again",
+			Error:   "invalid_challenge",
+		})
+		return
+	}
+	
+	// 错误的验证码与错误的密码一样计入失败次数
+	if !checkThrottle(c, h.guard, user.Username) {
+		return
+	}
+	// 挑战令牌验证通过后作废，不能重放；错误次数过多也作废，需要重新输入密码
+	var verifyErr error
+	passed, err := h.guard.Challenge(claims.ID, claims.ExpiresAt.Time, func(tx *gorm.DB) (bool, error) {
+		verifyErr = verifySecondFactor(tx, &user, req.Code, req.RecoveryCode)
+		if errors.Is(verifyErr, errInvalidMFACode) {
+			return false, nil
+		}
+		return verifyErr == nil, verifyErr
+	})
+	if err != nil {
+		releaseAttempt(c, h.guard, user.Username)
+	}
+	if errors.Is(err, loginguard.ErrChallengeInvalid) {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Login challenge is invalid or expired, please sign in again",
+			Error:   "invalid_challenge",
 		})
 		return
 	}
+	if err != nil {
+		writeSecondFactorError(c, err)
+		return
+	}
+	if !passed {
+		recordFailure(c, h.guard, user.Username)
+		writeSecondFactorError(c, verifyErr)
+		return
+	}
 	
-	// 生成JWT token
-	token, err := middleware.GenerateJWT(&user, h.config)
+	recordSuccess(c, h.guard, user.Username)
+	h.completeLogin(c, &user)
+}
+
+// checkThrottle 用户名或IP需要等待时返回429并带上Retry-After；失败计数读取失败时放行
+// 放行时这次尝试记为进行中，之后须调用recordFailure、recordSuccess或releaseAttempt之一
+func checkThrottle(c *gin.Context, guard *loginguard.Guard, username string) bool {
+	wait, err := guard.Attempt(username, c.ClientIP())
+	if err != nil {
+		log.Printf("Failed to check login throttle: %v", err)
+		return true
+	}
+	if wait.RetryAfter <= 0 {
+		return true
+	}
+	
+	seconds := int(math.Ceil(wait.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	message, code := "Too many failed attempts, please try again later", "too_many_attempts"
+	if wait.Locked {
+		message, code = "Too many failed attempts, login is temporarily locked", "login_locked"
+	}
+	c.JSON(http.StatusTooManyRequests, models.APIResponse{
+		Success: false,
+		Message: message,
+		Error:   code,
+		Data:    gin.H{"retryAfter": seconds},
+	})
+	return false
+}
+
+func recordFailure(c *gin.Context, guard *loginguard.Guard, username string) {
+	if err := guard.Fail(username, c.ClientIP()); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+	}
+}
+
+func recordSuccess(c *gin.Context, guard *loginguard.Guard, username string) {
+	if err := guard.Succeed(username, c.ClientIP()); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+}
+
+func releaseAttempt(c *gin.Context, guard *loginguard.Guard, username string) {
+	if err := guard.Release(username, c.ClientIP()); err != nil {
+		log.Printf("Failed to release login attempt: %v", err)
+	}
+}
+
+// completeLogin 身份验证全部通过后创建会话并签发令牌
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.User) {
+	db := database.GetDB()
+	sess, refreshToken, err := session.Create(db, user.ID, c.Request.UserAgent(), c.ClientIP(), h.config.RefreshTokenTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create session",
+			Error:   err.Error(),
+		})
+		return
+	}
+	token, err := middleware.GenerateJWT(user, sess.ID, h.config)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -68,8 +239,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 	
 	response := models.LoginResponse{
-		Token: token,
-		User:  user,
+		Token:            token,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int(h.config.AccessTokenTTL.Seconds()),
+		User:             *user,
+		MFASetupRequired: !user.TOTPEnabled && mfaRequired(user),
 	}
 	
 	c.JSON(http.StatusOK, models.APIResponse{
@@ -248,23 +422,33 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	})
 }
 
-// RefreshToken 刷新token
+// RefreshToken 用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌随即作废
+// 已作废的刷新令牌再次使用时撤销整个会话，客户端需要串行刷新
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, models.APIResponse{
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "User not found",
-			Error:   "user_not_found",
+			Message: "Invalid request data",
+			Error:   err.Error(),
 		})
 		return
 	}
 	
 	db := database.GetDB()
-	var user models.User
+	sess, refreshToken, err := session.Rotate(db, req.RefreshToken, h.config.RefreshTokenTTL)
+	if err != nil {
+		h.refreshFailed(c, err)
+		return
+	}
 	
-	if err := db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
+	// 用户被停用或删除后不再续期
+	var user models.User
+	if err := db.Where("active = ?", true).First(&user, sess.UserID).Error; err != nil {
+		if err := session.Revoke(db, sess.ID, session.ReasonInactive); err != nil {
+			log.Printf("Failed to revoke session %d: %v", sess.ID, err)
+		}
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
 			Message: "User not found",
 			Error:   "user_not_found",
@@ -272,8 +456,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 	
-	// 生成新的JWT token
-	token, err := middleware.GenerateJWT(&user, h.config)
+	token, err := middleware.GenerateJWT(&user, sess.ID, h.config)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -286,6 +469,141 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Token refreshed successfully",
-		Data:    gin.H{"token": token},
+		Data: models.TokenResponse{
+			Token:        token,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int(h.config.AccessTokenTTL.Seconds()),
+		},
+	})
+}
+
+// Logout 退出当前会话，会话的访问令牌和刷新令牌一并失效
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID, _ := c.Get("session_id")
+	if err := session.Revoke(database.GetDB(), sessionID.(uint), session.ReasonLogout); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to log out",
+			Error:   err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Logged out successfully",
 	})
-}
\ No newline at end of file
+}
+
+// LogoutAll 退出当前用户的全部会话，包括当前会话
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	revoked, err := session.RevokeAll(database.GetDB(), userID.(uint), session.ReasonLogoutAll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to log out",
+			Error:   err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Logged out of all sessions",
+		Data:    gin.H{"revoked": revoked},
+	})
+}
+
+// GetSessions 当前用户仍然有效的登录会话（需要认证）
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	currentID, _ := c.Get("session_id")
+	
+	sessions, err := session.List(database.GetDB(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch sessions",
+			Error:   err.Error(),
+		})
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID.(uint)
+	}
+	
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Sessions fetched successfully",
+		Data:    sessions,
+	})
+}
+
+// RevokeSession 注销当前用户的某个会话，可以是当前会话（需要认证）
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid session ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+	
+	userID, _ := c.Get("user_id")
+	db := database.GetDB()
+	var sess models.Session
+	// 其他用户的会话同样返回404，不暴露是否存在
+	if err := db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).First(&sess).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Session not found",
+			Error:   "session_not_found",
+		})
+		return
+	}
+	
+	if err := session.Revoke(db, sess.ID, session.ReasonRevoked); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to revoke session",
+			Error:   err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Session revoked successfully",
+	})
+}
+
+// refreshFailed 刷新令牌无效时统一返回401，按原因区分错误码
+func (h *AuthHandler) refreshFailed(c *gin.Context, err error) {
+	var message, code string
+	switch {
+	case errors.Is(err, session.ErrInvalidToken):
+		message, code = "Invalid refresh token", "invalid_refresh_token"
+	case errors.Is(err, session.ErrTokenReused):
+		message, code = "Refresh token was already used, session revoked", "refresh_token_reused"
+	case errors.Is(err, session.ErrRevoked):
+		message, code = "Session has been revoked", "session_revoked"
+	case errors.Is(err, session.ErrExpired):
+		message, code = "Session has expired", "session_expired"
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to refresh token",
+			Error:   err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusUnauthorized, models.APIResponse{
+		Success: false,
+		Message: message,
+		Error:   code,
+	})
+}
@@ -1,25 +1,43 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
-	"encoding/json"
 	"math"
+	"net/url"
 	"time"
 	
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/config"
 	"techblog-api/backend/internal/database"
 	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/search"
+	"techblog-api/backend/internal/slug"
+	"techblog-api/backend/internal/views"
 )
 
-type BlogHandler struct{}
+type BlogHandler struct {
+	config *config.Config
+	slugs  *slug.Generator
+	views  *views.Counter
+}
 
-func NewBlogHandler() *BlogHandler {
-	return &BlogHandler{}
+func NewBlogHandler(cfg *config.Config, viewCounter *views.Counter) *BlogHandler {
+	return &BlogHandler{
+		config: cfg,
+		views:  viewCounter,
+		slugs: slug.New(slug.Options{
+			MaxLength:        cfg.SlugMaxLength,
+			Transliterations: slug.ParseTransliterations(cfg.SlugTransliterations),
+		}),
+	}
 }
 
-// GetPosts 获取博客文章列表
+// GetPosts 获取已发布的博客文章列表
 func (h *BlogHandler) GetPosts(c *gin.Context) {
 	var query models.BlogPostQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
@@ -31,23 +49,75 @@ func (h *BlogHandler) GetPosts(c *gin.Context) {
 		return
 	}
 	
+	// 公开接口只返回已发布的文章
+	h.listPosts(c, query, models.Published)
+}
+
+// GetAdminPosts 获取全部文章列表，支持 status=draft|scheduled|published|trashed 过滤（需要管理员权限）
+func (h *BlogHandler) GetAdminPosts(c *gin.Context) {
+	var query models.AdminPostQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+	
+	scope := func(db *gorm.DB) *gorm.DB { return db }
+	switch query.Status {
+	case models.PostDraft:
+		scope = models.Drafts
+	case models.PostScheduled:
+		scope = models.Scheduled
+	case models.PostPublished:
+		scope = models.Published
+	case models.PostTrashed:
+		scope = models.Trashed
+	}
+	h.listPosts(c, query.BlogPostQuery, scope)
+}
+
+// listPosts 按查询参数分页返回文章，scope决定可见范围
+func (h *BlogHandler) listPosts(c *gin.Context, query models.BlogPostQuery, scope func(*gorm.DB) *gorm.DB) {
 	db := database.GetDB()
 	var posts []models.BlogPost
 	var total int64
 	
 	// 构建查询
-	dbQuery := db.Model(&models.BlogPost{})
+	dbQuery := db.Model(&models.BlogPost{}).Scopes(scope)
 	
-	// 搜索条件
+	// 搜索条件（全文检索）
+	lang := search.NormalizeLanguage(h.config.SearchLanguage)
+	searchQuery := search.Query(query.Search)
 	if query.Search != "" {
-		searchTerm := "%" + query.Search + "%"
-		dbQuery = dbQuery.Where("title ILIKE ? OR content ILIKE ? OR excerpt ILIKE ?", 
-			searchTerm, searchTerm, searchTerm)
+		dbQuery = search.Match(dbQuery, lang, searchQuery)
 	}
 	
-	// 标签过滤
-	if query.Tag != "" {
-		dbQuery = dbQuery.Where("tags ILIKE ?", "%"+query.Tag+"%")
+	// 标签过滤：精确匹配slug或名称，tag_mode=all时要求同时包含全部标签
+	if tagFilters := database.NormalizeTagNames(strings.Split(query.Tag, ",")); len(tagFilters) > 0 {
+		for i := range tagFilters {
+			tagFilters[i] = strings.ToLower(tagFilters[i])
+		}
+		tagged := db.Table("post_tags").Select("post_tags.blog_post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.slug IN ? OR lower(tags.name) IN ?", tagFilters, tagFilters)
+		if query.TagMode == "all" {
+			tagged = tagged.Group("post_tags.blog_post_id").
+				Having("COUNT(DISTINCT tags.id) >= ?", len(tagFilters))
+		}
+		dbQuery = dbQuery.Where("blog_posts.id IN (?)", tagged)
+	}
+	
+	// 分类过滤（支持slug或ID）
+	if query.Category != "" {
+		if categoryID, err := strconv.ParseUint(query.Category, 10, 32); err == nil {
+			dbQuery = dbQuery.Where("category_id = ?", categoryID)
+		} else {
+			dbQuery = dbQuery.Where("category_id IN (?)",
+				db.Model(&models.Category{}).Select("id").Where("slug = ?", query.Category))
+		}
 	}
 	
 	// 作者过滤
@@ -55,14 +125,6 @@ func (h *BlogHandler) GetPosts(c *gin.Context) {
 		dbQuery = dbQuery.Where("author ILIKE ?", "%"+query.Author+"%")
 	}
 	
-	// 发布状态过滤
-	if query.Published != nil {
-		dbQuery = dbQuery.Where("published = ?", *query.Published)
-	} else {
-		// 对于公开API，只显示已发布的文章
-		dbQuery = dbQuery.Where("published = ?", true)
-	}
-	
 	// 获取总数
 	if err := dbQuery.Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -73,9 +135,20 @@ func (h *BlogHandler) GetPosts(c *gin.Context) {
 		return
 	}
 	
+	// 附带已审核评论数，搜索时附带高亮片段
+	commentCount := "(SELECT COUNT(*) FROM comments WHERE comments.post_id = blog_posts.id AND comments.approved = true) AS comment_count"
+	if query.Search != "" {
+		dbQuery = dbQuery.Select("blog_posts.*, "+commentCount+", "+search.HeadlineExpr("content")+" AS highlight",
+			lang, lang, searchQuery, search.HeadlineOptions(2))
+	} else {
+		dbQuery = dbQuery.Select("blog_posts.*, " + commentCount)
+	}
+	
 	// 排序
 	orderBy := query.Sort + " " + strings.ToUpper(query.Order)
-	if query.Sort == "created_at" || query.Sort == "updated_at" || query.Sort == "view_count" || query.Sort == "read_time" {
+	if query.Sort == "relevance" && query.Search != "" {
+		dbQuery = search.OrderByRank(dbQuery, lang, searchQuery)
+	} else if query.Sort == "created_at" || query.Sort == "updated_at" || query.Sort == "view_count" || query.Sort == "read_time" {
 		dbQuery = dbQuery.Order(orderBy)
 	} else {
 		dbQuery = dbQuery.Order("created_at DESC")
@@ -83,7 +156,7 @@ func (h *BlogHandler) GetPosts(c *gin.Context) {
 	
 	// 分页
 	offset := (query.Page - 1) * query.Limit
-	if err := dbQuery.Offset(offset).Limit(query.Limit).Find(&posts).Error; err != nil {
+	if err := dbQuery.Preload("Category").Preload("Tags", orderTags).Offset(offset).Limit(query.Limit).Find(&posts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to fetch posts",
@@ -92,7 +165,17 @@ func (h *BlogHandler) GetPosts(c *gin.Context) {
 		return
 	}
 	
-	// 注意：这里不需要修改Tags字段，它已经是JSON字符串格式
+	// 将高亮片段转换为安全的HTML；列表不返回渲染后的正文和目录
+	refs := make([]*models.BlogPost, len(posts))
+	for i := range posts {
+		posts[i].ContentHTML = ""
+		posts[i].TOC = nil
+		if posts[i].Highlight != "" {
+			posts[i].Highlight = search.Highlight(posts[i].Highlight)
+		}
+		refs[i] = &posts[i]
+	}
+	attachCoverSrcset(h.config, database.GetDB(), refs...)
 	
 	// 计算分页信息
 	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
@@ -117,9 +200,39 @@ func (h *BlogHandler) GetPost(c *gin.Context) {
 	db := database.GetDB()
 	var post models.BlogPost
 	
+	// 携带预览令牌时可以查看草稿和定时发布的文章
+	lookup := db.Preload("Category").Preload("Tags", orderTags)
+	preview := c.Query("preview")
+	if preview != "" {
+		previewPostID, err := resolvePreview(db, preview)
+		if err != nil && !errors.Is(err, errInvalidPreview) {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to verify preview link",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "Preview link is invalid, expired or revoked",
+				Error:   "invalid_preview",
+			})
+			return
+		}
+		lookup = lookup.Where("blog_posts.id = ?", previewPostID)
+		
+		// 预览内容不允许被搜索引擎收录或被共享缓存保存
+		c.Header("X-Robots-Tag", "noindex, nofollow")
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		lookup = lookup.Scopes(models.Published)
+	}
+	
 	// 尝试按ID查找
 	if postID, err := strconv.ParseUint(id, 10, 32); err == nil {
-		if err := db.Where("id = ? AND published = ?", postID, true).First(&post).Error; err != nil {
+		if err := lookup.Where("id = ?", postID).First(&post).Error; err != nil {
 			c.JSON(http.StatusNotFound, models.APIResponse{
 				Success: false,
 				Message: "Post not found",
@@ -128,8 +241,22 @@ func (h *BlogHandler) GetPost(c *gin.Context) {
 			return
 		}
 	} else {
-		// 按slug查找
-		if err := db.Where("slug = ? AND published = ?", id, true).First(&post).Error; err != nil {
+		// 按slug查找，旧slug返回301指向当前地址
+		if err := lookup.Where("slug = ?", id).First(&post).Error; err != nil {
+			if current, ok := resolveSlugRedirect(db, id); ok {
+				location := "/api/v1/posts/" + url.PathEscape(current)
+				if c.Request.URL.RawQuery != "" {
+					location += "?" + c.Request.URL.RawQuery
+				}
+				c.Header("Location", location)
+				c.JSON(http.StatusMovedPermanently, models.APIResponse{
+					Success: false,
+					Message: "Post has moved",
+					Error:   "post_moved",
+					Data:    gin.H{"slug": current, "location": location},
+				})
+				return
+			}
 			c.JSON(http.StatusNotFound, models.APIResponse{
 				Success: false,
 				Message: "Post not found",
@@ -139,8 +266,26 @@ func (h *BlogHandler) GetPost(c *gin.Context) {
 		}
 	}
 	
-	// 增加浏览量
-	db.Model(&post).Update("view_count", post.ViewCount+1)
+	// 记录浏览量（预览不计入），由计数器去重后批量写入
+	if preview == "" {
+		h.views.Record(post.ID, c.ClientIP(), c.Request.UserAgent())
+	}
+	
+	// format=html返回渲染后的HTML，默认返回Markdown原文
+	switch c.DefaultQuery("format", "markdown") {
+	case "html":
+		post.Content = ""
+	case "markdown":
+		post.ContentHTML = ""
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid format, expected html or markdown",
+			Error:   "invalid_format",
+		})
+		return
+	}
+	attachCoverSrcset(h.config, db, &post)
 	
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -161,14 +306,20 @@ func (h *BlogHandler) CreatePost(c *gin.Context) {
 		return
 	}
 	
-	// 生成slug
-	slug := generateSlug(req.Title)
-	
-	// 检查slug是否已存在
+	// 生成slug，冲突时追加-2、-3等后缀
 	db := database.GetDB()
-	var existingPost models.BlogPost
-	if err := db.Where("slug = ?", slug).First(&existingPost).Error; err == nil {
-		slug = slug + "-" + strconv.FormatInt(time.Now().Unix(), 10)
+	base := req.Slug
+	if base == "" {
+		base = req.Title
+	}
+	slug, err := h.uniqueSlug(db, base, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate slug",
+			Error:   err.Error(),
+		})
+		return
 	}
 	
 	// 创建文章
@@ -178,18 +329,44 @@ func (h *BlogHandler) CreatePost(c *gin.Context) {
 		Excerpt:    req.Excerpt,
 		Slug:       slug,
 		Author:     req.Author,
-		Tags:       serializeTags(req.Tags),
+		CategoryID: req.CategoryID,
 		CoverImage: req.CoverImage,
-		Published:  req.Published,
 		ReadTime:   calculateReadTime(req.Content),
 	}
+	applyPublishState(&post, req)
 	
 	// 如果excerpt为空，自动生成
 	if post.Excerpt == "" {
 		post.Excerpt = generateExcerpt(req.Content)
 	}
 	
-	if err := db.Create(&post).Error; err != nil {
+	// 创建文章并在同一事务中更新分类文章数
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategory(tx, post.CategoryID); err != nil {
+			return err
+		}
+		tags, err := database.ResolveTags(tx, h.slugs, req.Tags)
+		if err != nil {
+			return err
+		}
+		post.Tags = tags
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := recordRevision(tx, h.config, &post, tags, currentUserID(c), ""); err != nil {
+			return err
+		}
+		return database.SyncCategoryPostCounts(tx, post.CategoryID)
+	})
+	if errors.Is(err, errCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+			Error:   "category_not_found",
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to create post",
@@ -198,6 +375,8 @@ func (h *BlogHandler) CreatePost(c *gin.Context) {
 		return
 	}
 	
+	db.Preload("Category").Preload("Tags", orderTags).First(&post, post.ID)
+	
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "Post created successfully",
@@ -239,14 +418,32 @@ func (h *BlogHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 	
+	// 指定了slug或标题变化时更新slug
+	newSlug := post.Slug
+	if req.Slug != "" || req.Title != post.Title {
+		base := req.Slug
+		if base == "" {
+			base = req.Title
+		}
+		if newSlug, err = h.uniqueSlug(db, base, post.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to generate slug",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+	
 	// 更新文章数据
+	previousCategoryID := post.CategoryID
 	post.Title = req.Title
 	post.Content = req.Content
 	post.Excerpt = req.Excerpt
 	post.Author = req.Author
-	post.Tags = serializeTags(req.Tags)
+	post.CategoryID = req.CategoryID
 	post.CoverImage = req.CoverImage
-	post.Published = req.Published
+	applyPublishState(&post, req)
 	post.ReadTime = calculateReadTime(req.Content)
 	
 	// 如果excerpt为空，自动生成
@@ -254,7 +451,59 @@ func (h *BlogHandler) UpdatePost(c *gin.Context) {
 		post.Excerpt = generateExcerpt(req.Content)
 	}
 	
-	if err := db.Save(&post).Error; err != nil {
+	// 分类或发布状态可能变化，原分类和新分类的文章数都要重新统计；保存后记录新版本
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategory(tx, previousCategoryID); err != nil && !errors.Is(err, errCategoryNotFound) {
+			return err
+		}
+		if err := lockCategory(tx, post.CategoryID); err != nil {
+			return err
+		}
+		// 先锁住文章行，并发保存时基线版本只写一次，版本号也不会重复
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.BlogPost{}, post.ID).Error; err != nil {
+			return err
+		}
+		if err := ensureBaselineRevision(tx, h.config, post.ID); err != nil {
+			return err
+		}
+		if err := changeSlug(tx, &post, newSlug); err != nil {
+			return err
+		}
+		// 浏览量由计数器原子累加，文章是在事务外读取的，整行保存会用旧值覆盖期间写入的浏览量
+		if err := tx.Omit(clause.Associations, "view_count").Save(&post).Error; err != nil {
+			return err
+		}
+		tags, err := database.ResolveTags(tx, h.slugs, req.Tags)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&post).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, h.config, &post, tags, currentUserID(c), ""); err != nil {
+			return err
+		}
+		return database.SyncCategoryPostCounts(tx, previousCategoryID, post.CategoryID)
+	})
+	if errors.Is(err, errCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+			Error:   "category_not_found",
+		})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 保存前文章已被移入回收站
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found",
+			Error:   "post_not_found",
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to update post",
@@ -263,6 +512,8 @@ func (h *BlogHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 	
+	db.Preload("Category").Preload("Tags", orderTags).First(&post, post.ID)
+	
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Post updated successfully",
@@ -270,7 +521,7 @@ func (h *BlogHandler) UpdatePost(c *gin.Context) {
 	})
 }
 
-// DeletePost 删除博客文章（需要管理员权限）
+// DeletePost 将博客文章移入回收站（需要管理员权限）
 func (h *BlogHandler) DeletePost(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -284,7 +535,28 @@ func (h *BlogHandler) DeletePost(c *gin.Context) {
 	
 	db := database.GetDB()
 	
-	if err := db.Delete(&models.BlogPost{}, id).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var post models.BlogPost
+		if err := tx.Select("id", "category_id").First(&post, id).Error; err != nil {
+			return err
+		}
+		if err := lockCategory(tx, post.CategoryID); err != nil && !errors.Is(err, errCategoryNotFound) {
+			return err
+		}
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+		return database.SyncCategoryPostCounts(tx, post.CategoryID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found",
+			Error:   "post_not_found",
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to delete post",
@@ -295,38 +567,87 @@ func (h *BlogHandler) DeletePost(c *gin.Context) {
 	
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Post deleted successfully",
+		Message: "Post moved to trash",
 	})
 }
 
 // 辅助函数
-func parseTags(tagsJSON string) []string {
-	var tags []string
-	if tagsJSON != "" {
-		json.Unmarshal([]byte(tagsJSON), &tags)
+// applyPublishState 根据请求设置发布状态：
+// publishAt在未来时为定时发布，由后台任务到点上线；否则按published立即发布或转为草稿
+func applyPublishState(post *models.BlogPost, req models.BlogPostRequest) {
+	now := time.Now()
+	switch {
+	case req.PublishAt != nil && req.PublishAt.After(now):
+		post.Published = false
+		post.PublishAt = req.PublishAt
+	case req.Published:
+		if req.PublishAt != nil {
+			post.PublishAt = req.PublishAt
+		} else if !post.Published {
+			post.PublishAt = &now
+		}
+		post.Published = true
+	default:
+		post.Published = false
+		post.PublishAt = nil
 	}
-	return tags
 }
 
-func serializeTags(tags []string) string {
-	if tags == nil {
-		return "[]"
-	}
-	tagsJSON, _ := json.Marshal(tags)
-	return string(tagsJSON)
+// publishedPosts 公开可见的文章查询
+func publishedPosts(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.BlogPost{}).Scopes(models.Published)
+}
+
+// orderTags 预加载标签时按名称排序
+func orderTags(db *gorm.DB) *gorm.DB {
+	return db.Order("tags.name ASC")
 }
 
-func generateSlug(title string) string {
-	slug := strings.ToLower(title)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	// 移除特殊字符，只保留字母、数字和连字符
-	var result strings.Builder
-	for _, r := range slug {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
-			result.WriteRune(r)
+// uniqueSlug 根据标题或指定的slug生成未被占用的slug
+// 回收站中的文章不占用slug；其他文章的旧slug仍用于重定向，视为已占用
+func (h *BlogHandler) uniqueSlug(db *gorm.DB, base string, postID uint) (string, error) {
+	return h.slugs.Unique(h.slugs.Make(base), func(candidate string) (bool, error) {
+		var count int64
+		if err := db.Model(&models.BlogPost{}).Where("slug = ? AND id <> ?", candidate, postID).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+		err := db.Model(&models.SlugRedirect{}).Where("slug = ? AND post_id <> ?", candidate, postID).Count(&count).Error
+		return count > 0, err
+	})
+}
+
+// changeSlug 修改文章slug并保留旧slug用于重定向，需在保存文章的事务中调用
+func changeSlug(tx *gorm.DB, post *models.BlogPost, newSlug string) error {
+	if newSlug == post.Slug {
+		return nil
+	}
+	// 改回曾经用过的slug时，删除对应的重定向
+	if err := tx.Where("slug = ? AND post_id = ?", newSlug, post.ID).Delete(&models.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	// 旧slug已被其他文章使用时（如恢复回收站中的文章）不记录重定向，否则会把访问者带离那篇文章
+	var taken int64
+	if err := tx.Model(&models.BlogPost{}).Where("slug = ? AND id <> ?", post.Slug, post.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken == 0 {
+		// 旧slug上残留的其他文章的重定向已经失效，改为指向当前放弃它的文章
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "slug"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"post_id":     post.ID,
+				"hits":        0,
+				"last_hit_at": nil,
+			}),
+		}).Create(&models.SlugRedirect{Slug: post.Slug, PostID: post.ID}).Error; err != nil {
+			return err
 		}
 	}
-	return result.String()
+	post.Slug = newSlug
+	return nil
 }
 
 func calculateReadTime(content string) int {
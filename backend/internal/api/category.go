@@ -0,0 +1,262 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/slug"
+)
+
+// errCategoryNotFound 文章引用的分类不存在
+var errCategoryNotFound = errors.New("category not found")
+
+type CategoryHandler struct {
+	config *config.Config
+	slugs  *slug.Generator
+}
+
+func NewCategoryHandler(cfg *config.Config) *CategoryHandler {
+	return &CategoryHandler{
+		config: cfg,
+		slugs: slug.New(slug.Options{
+			MaxLength:        cfg.SlugMaxLength,
+			Transliterations: slug.ParseTransliterations(cfg.SlugTransliterations),
+		}),
+	}
+}
+
+// GetCategories 获取分类列表及已发布文章数
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	db := database.GetDB()
+	var categories []models.Category
+
+	if err := db.Order("name ASC").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch categories",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Categories fetched successfully",
+		Data:    categories,
+	})
+}
+
+// GetCategory 获取单个分类（需要管理员权限）
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var category models.Category
+
+	if err := db.First(&category, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+			Error:   "category_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category fetched successfully",
+		Data:    category,
+	})
+}
+
+// CreateCategory 创建分类（需要管理员权限）
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	category := models.Category{
+		Name:        strings.TrimSpace(req.Name),
+		Slug:        h.categorySlug(req),
+		Description: req.Description,
+		Color:       req.Color,
+	}
+	if category.Color == "" {
+		category.Color = "#6366f1"
+	}
+
+	db := database.GetDB()
+	if err := db.Create(&category).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Failed to create category, name or slug may already exist",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Category created successfully",
+		Data:    category,
+	})
+}
+
+// UpdateCategory 更新分类（需要管理员权限）
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var category models.Category
+
+	if err := db.First(&category, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+			Error:   "category_not_found",
+		})
+		return
+	}
+
+	category.Name = strings.TrimSpace(req.Name)
+	category.Slug = h.categorySlug(req)
+	category.Description = req.Description
+	if req.Color != "" {
+		category.Color = req.Color
+	}
+
+	// PostCount由文章变更维护，这里不覆盖
+	if err := db.Model(&category).Select("name", "slug", "description", "color").Updates(&category).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Failed to update category, name or slug may already exist",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category updated successfully",
+		Data:    category,
+	})
+}
+
+// DeleteCategory 删除分类，原分类下的文章变为未分类（需要管理员权限）
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.BlogPost{}).Where("category_id = ?", id).
+			Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		// 直接物理删除，避免软删除的记录继续占用名称和slug
+		result := tx.Unscoped().Delete(&models.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCategoryNotFound
+		}
+		return nil
+	})
+
+	if errors.Is(err, errCategoryNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+			Error:   "category_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete category",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category deleted successfully",
+	})
+}
+
+// categorySlug 优先使用请求中的slug，否则根据名称生成
+func (h *CategoryHandler) categorySlug(req models.CategoryRequest) string {
+	if s := h.slugs.Make(req.Slug); s != "" {
+		return s
+	}
+	if s := h.slugs.Make(req.Name); s != "" {
+		return s
+	}
+	return slug.Fallback
+}
+
+// lockCategory 在事务中锁定分类行，保证文章数统计串行更新
+func lockCategory(tx *gorm.DB, categoryID *uint) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	var category models.Category
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, *categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errCategoryNotFound
+		}
+		return err
+	}
+	return nil
+}
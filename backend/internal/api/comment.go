@@ -0,0 +1,392 @@
+package api
+
+import (
+	"html"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/microcosm-cc/bluemonday"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+// commentPolicy 评论只允许纯文本，script/style内的文本整体丢弃
+var commentPolicy = bluemonday.StrictPolicy()
+
+type CommentHandler struct {
+	config *config.Config
+}
+
+func NewCommentHandler(cfg *config.Config) *CommentHandler {
+	return &CommentHandler{
+		config: cfg,
+	}
+}
+
+// GetComments 获取文章下已审核的评论（楼层嵌套）
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	db := database.GetDB()
+
+	post, err := findPublishedPost(db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found",
+			Error:   "post_not_found",
+		})
+		return
+	}
+
+	var comments []models.Comment
+	if err := db.Where("post_id = ? AND approved = ?", post.ID, true).
+		Order("created_at ASC").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch comments",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Comments fetched successfully",
+		Data:    buildCommentTree(comments),
+	})
+}
+
+// CreateComment 发表评论，需审核后才会公开显示
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	var req models.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if req.Website != "" && !isHTTPURL(req.Website) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Website must be an http or https URL",
+			Error:   "invalid_website",
+		})
+		return
+	}
+
+	content := sanitizeCommentContent(req.Content)
+	author := strings.TrimSpace(sanitizeCommentContent(req.Author))
+	if content == "" || author == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Comment content and author cannot be empty",
+			Error:   "empty_comment",
+		})
+		return
+	}
+
+	db := database.GetDB()
+
+	post, err := findPublishedPost(db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found",
+			Error:   "post_not_found",
+		})
+		return
+	}
+
+	comment := models.Comment{
+		PostID:  post.ID,
+		Author:  author,
+		Email:   strings.TrimSpace(req.Email),
+		Website: strings.TrimSpace(req.Website),
+		Content: content,
+		Status:  models.CommentPending,
+	}
+
+	// 回复：父评论必须属于同一篇文章、已审核，且未超过最大深度
+	if req.ParentID != nil {
+		var parent models.Comment
+		if err := db.Where("id = ? AND post_id = ? AND approved = ?", *req.ParentID, post.ID, true).
+			First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Parent comment not found",
+				Error:   "parent_not_found",
+			})
+			return
+		}
+
+		if parent.Depth+1 > h.config.CommentMaxDepth {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Maximum reply depth exceeded",
+				Error:   "max_depth_exceeded",
+			})
+			return
+		}
+
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := db.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to submit comment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Comment submitted and awaiting moderation",
+		Data:    comment,
+	})
+}
+
+// GetModerationQueue 获取评论列表（需要管理员权限），默认返回待审核评论
+func (h *CommentHandler) GetModerationQueue(c *gin.Context) {
+	var req models.CommentQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var comments []models.Comment
+	var total int64
+
+	query := db.Model(&models.Comment{})
+	if req.Status != "all" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.PostID != 0 {
+		query = query.Where("post_id = ?", req.PostID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to count comments",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Preload("Post", func(tx *gorm.DB) *gorm.DB {
+		return tx.Select("id", "title", "slug")
+	}).Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch comments",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.Limit)))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Comments fetched successfully",
+		Data:    comments,
+		Meta: &models.PaginationMeta{
+			Page:       req.Page,
+			Limit:      req.Limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// ApproveComment 审核通过评论（需要管理员权限）
+func (h *CommentHandler) ApproveComment(c *gin.Context) {
+	h.moderate(c, "approve")
+}
+
+// RejectComment 拒绝评论（需要管理员权限）
+func (h *CommentHandler) RejectComment(c *gin.Context) {
+	h.moderate(c, "reject")
+}
+
+// MarkCommentSpam 标记为垃圾评论（需要管理员权限）
+func (h *CommentHandler) MarkCommentSpam(c *gin.Context) {
+	h.moderate(c, "spam")
+}
+
+// DeleteComment 删除评论及其所有回复（需要管理员权限）
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	h.moderate(c, "delete")
+}
+
+// BulkModerate 批量审核评论（需要管理员权限）
+func (h *CommentHandler) BulkModerate(c *gin.Context) {
+	var req models.CommentBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	affected, err := applyModeration(database.GetDB(), req.IDs, req.Action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to moderate comments",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Comments moderated successfully",
+		Data:    gin.H{"action": req.Action, "affected": affected},
+	})
+}
+
+// moderate 对单条评论执行审核操作
+func (h *CommentHandler) moderate(c *gin.Context, action string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid comment ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	affected, err := applyModeration(database.GetDB(), []uint{uint(id)}, action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to moderate comment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if affected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Comment not found",
+			Error:   "comment_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Comment moderated successfully",
+		Data:    gin.H{"id": id, "action": action},
+	})
+}
+
+// applyModeration 执行审核操作，返回受影响的评论数
+func applyModeration(db *gorm.DB, ids []uint, action string) (int64, error) {
+	if action == "delete" {
+		// 连同所有下级回复一起删除
+		result := db.Exec(`WITH RECURSIVE thread AS (
+			SELECT id FROM comments WHERE id IN ?
+			UNION
+			SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id
+		)
+		DELETE FROM comments WHERE id IN (SELECT id FROM thread)`, ids)
+		return result.RowsAffected, result.Error
+	}
+
+	statuses := map[string]string{
+		"approve": models.CommentApproved,
+		"reject":  models.CommentRejected,
+		"spam":    models.CommentSpam,
+	}
+
+	status := statuses[action]
+	result := db.Model(&models.Comment{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":   status,
+		"approved": status == models.CommentApproved,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// findPublishedPost 按ID或slug查找已发布的文章
+func findPublishedPost(db *gorm.DB, idOrSlug string) (*models.BlogPost, error) {
+	var post models.BlogPost
+	query := db.Scopes(models.Published)
+	if postID, err := strconv.ParseUint(idOrSlug, 10, 32); err == nil {
+		query = query.Where("id = ?", postID)
+	} else {
+		query = query.Where("slug = ?", idOrSlug)
+	}
+
+	if err := query.First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// buildCommentTree 将平铺的评论组装为楼层结构，父评论未公开的回复不显示
+func buildCommentTree(comments []models.Comment) []*models.CommentNode {
+	nodes := make(map[uint]*models.CommentNode, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &models.CommentNode{
+			ID:        comment.ID,
+			ParentID:  comment.ParentID,
+			Depth:     comment.Depth,
+			Author:    comment.Author,
+			Website:   comment.Website,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			Replies:   []*models.CommentNode{},
+		}
+	}
+
+	roots := []*models.CommentNode{}
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if comment.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+	return roots
+}
+
+// sanitizeCommentContent 去除评论中的HTML标签，保存为未转义的纯文本
+// 评论以JSON返回，由前端按文本渲染时转义；转义后再存会让"Tom & Jerry"显示成"Tom &amp; Jerry"
+func sanitizeCommentContent(content string) string {
+	return strings.TrimSpace(html.UnescapeString(commentPolicy.Sanitize(content)))
+}
+
+// isHTTPURL 判断是否为http/https链接
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
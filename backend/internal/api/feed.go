@@ -0,0 +1,205 @@
+package api
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/feed"
+	"techblog-api/backend/internal/models"
+)
+
+type FeedHandler struct {
+	config *config.Config
+}
+
+func NewFeedHandler(cfg *config.Config) *FeedHandler {
+	return &FeedHandler{
+		config: cfg,
+	}
+}
+
+// RSS 输出RSS 2.0订阅源，支持 /tags/:slug 与 /authors/:author 前缀
+func (h *FeedHandler) RSS(c *gin.Context) {
+	h.serve(c, "rss")
+}
+
+// Atom 输出Atom 1.0订阅源
+func (h *FeedHandler) Atom(c *gin.Context) {
+	h.serve(c, "atom")
+}
+
+// JSON 输出JSON Feed 1.1订阅源
+func (h *FeedHandler) JSON(c *gin.Context) {
+	h.serve(c, "json")
+}
+
+func (h *FeedHandler) serve(c *gin.Context, format string) {
+	db := database.GetDB()
+	title := h.config.SiteTitle
+	scope := publishedPosts(db)
+
+	// 按标签或作者过滤
+	if tagSlug := c.Param("slug"); tagSlug != "" {
+		var tag models.Tag
+		if err := db.Where("slug = ?", tagSlug).First(&tag).Error; err != nil {
+			c.String(http.StatusNotFound, "feed not found")
+			return
+		}
+		scope = scope.Where("blog_posts.id IN (?)",
+			db.Table("post_tags").Select("blog_post_id").Where("tag_id = ?", tag.ID))
+		title = fmt.Sprintf("%s - %s", title, tag.Name)
+	} else if author := c.Param("author"); author != "" {
+		scope = scope.Where("lower(blog_posts.author) = lower(?)", author)
+		title = fmt.Sprintf("%s - %s", title, author)
+	}
+
+	// 以最新的更新时间和文章数生成缓存校验值，聚合器轮询时可直接返回304
+	var stats struct {
+		LastModified *time.Time
+		Count        int64
+	}
+	if err := scope.Session(&gorm.Session{}).
+		Select("MAX(blog_posts.updated_at) AS last_modified, COUNT(*) AS count").
+		Scan(&stats).Error; err != nil {
+		c.String(http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+
+	lastModified := time.Unix(0, 0).UTC()
+	if stats.LastModified != nil {
+		lastModified = stats.LastModified.UTC()
+	}
+	etag := fmt.Sprintf(`W/"%x"`, sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%d|%t",
+		format, c.Request.URL.Path, lastModified.UnixNano(), stats.Count, h.config.FeedFullContent))))
+	if notModified(c, etag, lastModified) {
+		return
+	}
+
+	var posts []models.BlogPost
+	if err := scope.Preload("Tags", orderTags).
+		Order("blog_posts.created_at DESC").
+		Limit(h.config.FeedItemLimit).
+		Find(&posts).Error; err != nil {
+		c.String(http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+
+	f := &feed.Feed{
+		Title:       title,
+		Description: h.config.SiteDescription,
+		Link:        absoluteURL(h.config, "/"),
+		FeedURL:     absoluteURL(h.config, c.Request.URL.Path),
+		Language:    h.config.SiteLanguage,
+		Updated:     lastModified,
+	}
+	for _, post := range posts {
+		f.Items = append(f.Items, h.feedItem(post))
+	}
+
+	var (
+		body        []byte
+		err         error
+		contentType string
+	)
+	switch format {
+	case "atom":
+		body, err = f.Atom()
+		contentType = feed.AtomContentType
+	case "json":
+		body, err = f.JSON()
+		contentType = feed.JSONContentType
+	default:
+		body, err = f.RSS()
+		contentType = feed.RSSContentType
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// feedItem 将文章转换为订阅源条目
+func (h *FeedHandler) feedItem(post models.BlogPost) feed.Item {
+	link := postURL(h.config, post.Slug)
+	item := feed.Item{
+		ID:        postFeedID(h.config, post),
+		Title:     post.Title,
+		Link:      link,
+		Summary:   post.Excerpt,
+		Author:    post.Author,
+		Published: post.CreatedAt,
+		Updated:   post.UpdatedAt,
+	}
+
+	if post.PublishAt != nil {
+		item.Published = *post.PublishAt
+	}
+	if h.config.FeedFullContent {
+		item.ContentHTML = post.ContentHTML
+	}
+	if post.CoverImage != "" {
+		item.Image = absoluteURL(h.config, post.CoverImage)
+	}
+	for _, tag := range post.Tags {
+		item.Categories = append(item.Categories, tag.Name)
+	}
+
+	return item
+}
+
+// notModified 设置ETag和Last-Modified，客户端缓存仍有效时返回304
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "public, max-age=300")
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			if strings.TrimSpace(candidate) == etag || strings.TrimSpace(candidate) == "*" {
+				c.Status(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+
+	if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil {
+		if !lastModified.Truncate(time.Second).After(since) {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// absoluteURL 将站内路径转换为绝对地址
+func absoluteURL(cfg *config.Config, p string) string {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+		return p
+	}
+	return strings.TrimRight(cfg.SiteURL, "/") + "/" + strings.TrimLeft(p, "/")
+}
+
+// postURL 文章在前端的访问地址
+func postURL(cfg *config.Config, slug string) string {
+	return absoluteURL(cfg, "/blog/"+slug)
+}
+
+// postFeedID 文章在订阅源中的唯一标识，使用tag URI（RFC 4151），修改slug后阅读器不会把文章当成新条目
+func postFeedID(cfg *config.Config, post models.BlogPost) string {
+	host := "localhost"
+	if u, err := url.Parse(cfg.SiteURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:post-%d", host, post.CreatedAt.UTC().Format("2006-01-02"), post.ID)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/loginguard"
+	"techblog-api/backend/internal/models"
+)
+
+type LockoutHandler struct {
+	config *config.Config
+	guard  *loginguard.Guard
+}
+
+func NewLockoutHandler(cfg *config.Config, guard *loginguard.Guard) *LockoutHandler {
+	return &LockoutHandler{
+		config: cfg,
+		guard:  guard,
+	}
+}
+
+// GetLockouts 获取登录锁定记录（需要管理员权限）
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	var query models.LockoutQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	q := db.Model(&models.LoginLockout{})
+	if query.Active {
+		q = q.Where("unlocked_at IS NULL AND locked_until > ?", time.Now())
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to count lockouts",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var lockouts []models.LoginLockout
+	offset := (query.Page - 1) * query.Limit
+	if err := q.Order("created_at DESC").Offset(offset).Limit(query.Limit).Find(&lockouts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch lockouts",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Lockouts fetched successfully",
+		Data:    lockouts,
+		Meta: &models.PaginationMeta{
+			Page:       query.Page,
+			Limit:      query.Limit,
+			Total:      total,
+			TotalPages: int(math.Ceil(float64(total) / float64(query.Limit))),
+		},
+	})
+}
+
+// Unlock 解除用户名或IP的登录锁定，同时清空失败计数（需要管理员权限）
+func (h *LockoutHandler) Unlock(c *gin.Context) {
+	var req models.UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.IP = strings.TrimSpace(req.IP)
+	if req.Username == "" && req.IP == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Username or IP is required",
+			Error:   "missing_target",
+		})
+		return
+	}
+	if req.IP != "" && loginguard.IPKey(req.IP) == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid IP address",
+			Error:   "invalid_ip",
+		})
+		return
+	}
+
+	unlocked, err := h.guard.Unlock(req.Username, req.IP, currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to unlock",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Unlocked successfully",
+		Data:    gin.H{"unlocked": unlocked},
+	})
+}
@@ -0,0 +1,579 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/media"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/storage"
+)
+
+// hashedFileName 以内容哈希命名的文件（含缩略图）内容不会变化，可以长期缓存
+var hashedFileName = regexp.MustCompile(`^[0-9a-f]{64}(-w[0-9]+)?\.[a-z]+$`)
+
+type MediaHandler struct {
+	config   *config.Config
+	storage  storage.Storage
+	variants *media.Variants
+}
+
+func NewMediaHandler(cfg *config.Config, store storage.Storage) *MediaHandler {
+	return &MediaHandler{
+		config:   cfg,
+		storage:  store,
+		variants: media.NewVariants(store, cfg.ImageVariantWidths, cfg.ImageMaxPixels),
+	}
+}
+
+// ServeUpload 提供本地存储中的文件，?w=640返回对应宽度的缩略图，客户端支持WebP且体积更小时返回WebP
+func (h *MediaHandler) ServeUpload(c *gin.Context) {
+	key, ok := h.resolveUpload(c)
+	if !ok {
+		return
+	}
+
+	fs, ok := h.storage.(storage.FileSystem)
+	if !ok {
+		h.fileNotFound(c)
+		return
+	}
+	file := fs.FilePath(key)
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		h.fileNotFound(c)
+		return
+	}
+	if hashedFileName.MatchString(path.Base(key)) {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	c.File(file)
+}
+
+// RedirectUpload 对象存储中的文件跳转到公开地址或签名地址，参数与ServeUpload相同
+func (h *MediaHandler) RedirectUpload(c *gin.Context) {
+	key, ok := h.resolveUpload(c)
+	if !ok {
+		return
+	}
+
+	target, err := h.storage.URL(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate file URL",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 签名地址在有效期内可以复用，缓存跳转才能让浏览器命中图片缓存
+	maxAge := h.config.S3URLExpiry / 2
+	if h.config.S3PublicURL != "" {
+		maxAge = 24 * time.Hour
+	}
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	c.Redirect(http.StatusFound, target)
+}
+
+// resolveUpload 解析请求的文件路径和缩略图宽度，返回要提供的对象键；失败时已写入响应
+func (h *MediaHandler) resolveUpload(c *gin.Context) (string, bool) {
+	rel := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	// 缩略图缓存目录等隐藏文件不直接对外提供
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == "" || strings.HasPrefix(segment, ".") {
+			h.fileNotFound(c)
+			return "", false
+		}
+	}
+
+	w := c.Query("w")
+	if w == "" {
+		return rel, true
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil || width <= 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "w must be a positive integer",
+			Error:   "invalid_width",
+		})
+		return "", false
+	}
+
+	c.Header("Vary", "Accept")
+	variant, err := h.variants.Get(c.Request.Context(), rel, h.variants.Snap(width), strings.Contains(c.GetHeader("Accept"), "image/webp"))
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		h.fileNotFound(c)
+		return "", false
+	case err != nil:
+		// 生成失败时退回原图
+		log.Printf("Failed to generate image variant for %s: %v", rel, err)
+		return rel, true
+	}
+	return variant.Key, true
+}
+
+// UploadMedia 上传图片，multipart字段file为文件、alt为替代文本（需要管理员权限）
+// 相同内容的图片已存在时直接返回已有记录
+func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	// 为multipart边界和其他字段预留1MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.MaxFileSize+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.fileTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "A file is required in the \"file\" field",
+			Error:   "missing_file",
+		})
+		return
+	}
+	if header.Size > h.config.MaxFileSize {
+		h.fileTooLarge(c)
+		return
+	}
+
+	altText := strings.TrimSpace(c.PostForm("alt"))
+	if len([]rune(altText)) > 500 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Alt text must be at most 500 characters",
+			Error:   "invalid_alt_text",
+		})
+		return
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Failed to read uploaded file",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer src.Close()
+
+	data, err := media.ReadLimited(src, h.config.MaxFileSize)
+	if errors.Is(err, media.ErrTooLarge) {
+		h.fileTooLarge(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Failed to read uploaded file",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	file, err := media.Inspect(header.Filename, data)
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, models.APIResponse{
+			Success: false,
+			Message: "Only JPEG, PNG, GIF and WebP images are allowed",
+			Error:   "unsupported_type",
+		})
+		return
+	case errors.Is(err, media.ErrExtensionMismatch):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "File extension does not match the file content",
+			Error:   "extension_mismatch",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid image file",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var existing models.Media
+	if err := db.Where("hash = ?", file.Hash).First(&existing).Error; err == nil {
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Media already exists",
+			Data:    existing,
+		})
+		return
+	}
+
+	// 同名文件内容必然相同，已存在时不再写入
+	ctx := c.Request.Context()
+	_, err = h.storage.Stat(ctx, file.Path())
+	if errors.Is(err, storage.ErrNotFound) {
+		err = h.storage.Put(ctx, file.Path(), bytes.NewReader(file.Data), file.Size(), file.MimeType)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to save file",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	item := models.Media{
+		Hash:         file.Hash,
+		Path:         file.Path(),
+		URL:          mediaURL(file.Path()),
+		OriginalName: filepath.Base(header.Filename),
+		MimeType:     file.MimeType,
+		Size:         file.Size(),
+		Width:        file.Width,
+		Height:       file.Height,
+		AltText:      altText,
+		UploaderID:   currentUserID(c),
+	}
+	if err := db.Create(&item).Error; err != nil {
+		// 同一文件被并发上传时，另一个请求已经创建了记录
+		if db.Where("hash = ?", file.Hash).First(&existing).Error == nil {
+			c.JSON(http.StatusOK, models.APIResponse{
+				Success: true,
+				Message: "Media already exists",
+				Data:    existing,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create media",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Media uploaded successfully",
+		Data:    item,
+	})
+}
+
+// GetMediaList 获取媒体库列表，支持按文件名和替代文本搜索（需要管理员权限）
+func (h *MediaHandler) GetMediaList(c *gin.Context) {
+	var query models.MediaQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	dbQuery := db.Model(&models.Media{})
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		dbQuery = dbQuery.Where("original_name ILIKE ? OR alt_text ILIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to count media",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var items []models.Media
+	offset := (query.Page - 1) * query.Limit
+	if err := dbQuery.Preload("Uploader").
+		Order("created_at DESC, id DESC").
+		Offset(offset).Limit(query.Limit).
+		Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch media",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Media fetched successfully",
+		Data:    items,
+		Meta: &models.PaginationMeta{
+			Page:       query.Page,
+			Limit:      query.Limit,
+			Total:      total,
+			TotalPages: int(math.Ceil(float64(total) / float64(query.Limit))),
+		},
+	})
+}
+
+// GetMedia 获取单个媒体及引用它的文章（需要管理员权限）
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	item, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	references, err := mediaReferences(database.GetDB(), item.URL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch media references",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Media fetched successfully",
+		Data: gin.H{
+			"media":      item,
+			"references": references,
+		},
+	})
+}
+
+// UpdateMedia 修改替代文本（需要管理员权限）
+func (h *MediaHandler) UpdateMedia(c *gin.Context) {
+	item, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	var req models.MediaUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	if err := db.Model(item).Update("alt_text", strings.TrimSpace(req.AltText)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update media",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Media updated successfully",
+		Data:    item,
+	})
+}
+
+// DeleteMedia 删除媒体文件，仍被文章封面或正文引用时拒绝删除（需要管理员权限）
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	item, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	var references []models.MediaReference
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if references, err = mediaReferences(tx, item.URL); err != nil || len(references) > 0 {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete media",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if len(references) > 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Media is still used by posts",
+			Error:   "media_in_use",
+			Data:    references,
+		})
+		return
+	}
+
+	// 记录已删除，文件删除失败只会留下孤立文件
+	ctx := c.Request.Context()
+	if err := h.variants.RemoveVariants(ctx, item.Path); err != nil {
+		log.Printf("Failed to remove image variants for %s: %v", item.Path, err)
+	}
+	if err := h.storage.Delete(ctx, item.Path); err != nil {
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Media deleted, but the file could not be removed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Media deleted successfully",
+	})
+}
+
+// findMedia 按路径参数查找媒体；失败时已写入响应
+func (h *MediaHandler) findMedia(c *gin.Context) (*models.Media, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid media ID",
+			Error:   "invalid_id",
+		})
+		return nil, false
+	}
+
+	var item models.Media
+	if err := database.GetDB().Preload("Uploader").First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Media not found",
+			Error:   "media_not_found",
+		})
+		return nil, false
+	}
+	return &item, true
+}
+
+func (h *MediaHandler) fileNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.APIResponse{
+		Success: false,
+		Message: "File not found",
+		Error:   "file_not_found",
+	})
+}
+
+func (h *MediaHandler) fileTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+		Success: false,
+		Message: "File exceeds the maximum size of " + strconv.FormatInt(h.config.MaxFileSize>>20, 10) + "MB",
+		Error:   "file_too_large",
+	})
+}
+
+// mediaReferences 查找封面或正文引用了该地址的文章，包括回收站中的文章
+// 封面和正文中可能是相对路径，也可能带站点域名，因此按后缀和包含匹配
+func mediaReferences(db *gorm.DB, url string) ([]models.MediaReference, error) {
+	pattern := escapeLike(url)
+	var posts []models.BlogPost
+	if err := db.Unscoped().
+		Select("id, title, slug, published, publish_at, deleted_at").
+		Where("cover_image = ? OR cover_image LIKE ? OR content LIKE ?", url, "%"+pattern, "%"+pattern+"%").
+		Order("id ASC").
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	references := make([]models.MediaReference, 0, len(posts))
+	for _, post := range posts {
+		references = append(references, models.MediaReference{
+			PostID: post.ID,
+			Title:  post.Title,
+			Slug:   post.Slug,
+			Status: post.Status,
+		})
+	}
+	return references, nil
+}
+
+// attachCoverSrcset 封面是媒体库中的图片时，填充各宽度版本供前端生成srcset
+func attachCoverSrcset(cfg *config.Config, db *gorm.DB, posts ...*models.BlogPost) {
+	paths := make([]string, 0, len(posts))
+	for _, post := range posts {
+		if rel, ok := uploadPath(cfg, post.CoverImage); ok {
+			paths = append(paths, rel)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	var items []models.Media
+	if err := db.Select("path, url, mime_type, width").Where("path IN ?", paths).Find(&items).Error; err != nil {
+		log.Printf("Failed to load cover image media: %v", err)
+		return
+	}
+	byPath := make(map[string]models.Media, len(items))
+	for _, item := range items {
+		byPath[item.Path] = item
+	}
+
+	for _, post := range posts {
+		rel, ok := uploadPath(cfg, post.CoverImage)
+		if !ok {
+			continue
+		}
+		item, ok := byPath[rel]
+		if !ok {
+			continue
+		}
+		var sources []models.ImageSource
+		if media.Supports(item.MimeType) {
+			for _, width := range cfg.ImageVariantWidths {
+				if width < item.Width {
+					sources = append(sources, models.ImageSource{URL: item.URL + "?w=" + strconv.Itoa(width), Width: width})
+				}
+			}
+		}
+		post.CoverImageSrcset = append(sources, models.ImageSource{URL: item.URL, Width: item.Width})
+	}
+}
+
+// uploadPath 从站内上传文件的地址中取出相对于上传目录的路径
+func uploadPath(cfg *config.Config, url string) (string, bool) {
+	url = strings.TrimPrefix(url, strings.TrimRight(cfg.SiteURL, "/"))
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	if !strings.HasPrefix(url, "/uploads/") {
+		return "", false
+	}
+	return strings.TrimPrefix(url, "/uploads/"), true
+}
+
+// mediaURL 上传文件的访问路径
+func mediaURL(rel string) string {
+	return "/uploads/" + rel
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,469 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/loginguard"
+	"techblog-api/backend/internal/mfa"
+	"techblog-api/backend/internal/middleware"
+	"techblog-api/backend/internal/models"
+)
+
+var (
+	// errMFACodeRequired 没有提交验证码或恢复码
+	errMFACodeRequired = errors.New("mfa code required")
+	// errInvalidMFACode 验证码或恢复码错误，或验证码已使用过
+	errInvalidMFACode = errors.New("invalid mfa code")
+)
+
+type MFAHandler struct {
+	config *config.Config
+	guard  *loginguard.Guard
+}
+
+func NewMFAHandler(cfg *config.Config, guard *loginguard.Guard) *MFAHandler {
+	return &MFAHandler{
+		config: cfg,
+		guard:  guard,
+	}
+}
+
+// GetStatus 当前用户的两步验证状态（需要认证）
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var remaining int64
+	if err := database.GetDB().Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Count(&remaining).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to count recovery codes",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Two-factor status fetched successfully",
+		Data: gin.H{
+			"enabled":                user.TOTPEnabled,
+			"required":               mfaRequired(user),
+			"recoveryCodesRemaining": remaining,
+		},
+	})
+}
+
+// Setup 生成新的TOTP密钥，返回otpauth地址和二维码；验证通过后才会启用（需要认证）
+func (h *MFAHandler) Setup(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		h.alreadyEnabled(c)
+		return
+	}
+
+	enrollment, err := mfa.NewEnrollment(h.config.SiteTitle, user.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate two-factor secret",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := database.GetDB().Model(user).Updates(map[string]interface{}{
+		"totp_secret":    enrollment.Secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to save two-factor secret",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Scan the QR code with an authenticator app, then activate with a code",
+		Data:    enrollment,
+	})
+}
+
+// Activate 用验证器生成的验证码确认绑定，启用两步验证并返回恢复码（需要认证）
+// 同时返回新的访问令牌，其中记录了已启用两步验证
+func (h *MFAHandler) Activate(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		h.alreadyEnabled(c)
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Start two-factor setup first",
+			Error:   "mfa_setup_missing",
+		})
+		return
+	}
+
+	// 会话令牌可能被盗用，验证码与登录一样限制尝试次数
+	if !checkThrottle(c, h.guard, user.Username) {
+		return
+	}
+	db := database.GetDB()
+	if !h.verify(c, db, user, req.Code, "") {
+		return
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to enable two-factor authentication",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	token, ok := h.reissueToken(c, user)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Two-factor authentication enabled. Store the recovery codes somewhere safe",
+		Data: gin.H{
+			"recoveryCodes": codes,
+			"token":         token,
+			"expiresIn":     int(h.config.AccessTokenTTL.Seconds()),
+		},
+	})
+}
+
+// Disable 关闭两步验证，需要密码和验证码（或恢复码）；站点要求管理员启用时不允许关闭（需要认证）
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req models.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		h.notEnabled(c)
+		return
+	}
+	if mfaRequired(user) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Two-factor authentication is required for admin accounts",
+			Error:   "mfa_required_by_policy",
+		})
+		return
+	}
+	// 会话令牌可能被盗用，密码和验证码与登录一样限制尝试次数
+	if !checkThrottle(c, h.guard, user.Username) {
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		recordFailure(c, h.guard, user.Username)
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Password is incorrect",
+			Error:   "invalid_password",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	if !h.verify(c, db, user, req.Code, req.RecoveryCode) {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to disable two-factor authentication",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	token, ok := h.reissueToken(c, user)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+		Data: gin.H{
+			"token":     token,
+			"expiresIn": int(h.config.AccessTokenTTL.Seconds()),
+		},
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部作废（需要认证）
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		h.notEnabled(c)
+		return
+	}
+
+	if !checkThrottle(c, h.guard, user.Username) {
+		return
+	}
+	db := database.GetDB()
+	if !h.verify(c, db, user, req.Code, "") {
+		return
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate recovery codes",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Recovery codes regenerated",
+		Data:    gin.H{"recoveryCodes": codes},
+	})
+}
+
+// currentUser 加载当前登录用户；失败时已写入响应
+func (h *MFAHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, _ := c.Get("user_id")
+	var user models.User
+	if err := database.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   "user_not_found",
+		})
+		return nil, false
+	}
+	return &user, true
+}
+
+// verify 校验验证码或恢复码，错误的验证码和登录失败一样计数；失败时已写入响应
+// 调用前需已通过checkThrottle
+func (h *MFAHandler) verify(c *gin.Context, db *gorm.DB, user *models.User, code, recoveryCode string) bool {
+	err := verifySecondFactor(db, user, code, recoveryCode)
+	if errors.Is(err, errInvalidMFACode) {
+		recordFailure(c, h.guard, user.Username)
+	} else {
+		releaseAttempt(c, h.guard, user.Username)
+	}
+	if err != nil {
+		writeSecondFactorError(c, err)
+		return false
+	}
+	return true
+}
+
+// reissueToken 两步验证状态变化后为当前会话签发新的访问令牌；失败时已写入响应
+func (h *MFAHandler) reissueToken(c *gin.Context, user *models.User) (string, bool) {
+	var fresh models.User
+	if err := database.GetDB().First(&fresh, user.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to reload user",
+			Error:   err.Error(),
+		})
+		return "", false
+	}
+
+	sessionID, _ := c.Get("session_id")
+	token, err := middleware.GenerateJWT(&fresh, sessionID.(uint), h.config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate token",
+			Error:   err.Error(),
+		})
+		return "", false
+	}
+	return token, true
+}
+
+func (h *MFAHandler) alreadyEnabled(c *gin.Context) {
+	c.JSON(http.StatusConflict, models.APIResponse{
+		Success: false,
+		Message: "Two-factor authentication is already enabled",
+		Error:   "mfa_already_enabled",
+	})
+}
+
+func (h *MFAHandler) notEnabled(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, models.APIResponse{
+		Success: false,
+		Message: "Two-factor authentication is not enabled",
+		Error:   "mfa_not_enabled",
+	})
+}
+
+// writeSecondFactorError 按verifySecondFactor的错误写入响应
+func writeSecondFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errMFACodeRequired):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "A verification code or recovery code is required",
+			Error:   "mfa_code_required",
+		})
+	case errors.Is(err, errInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid verification code",
+			Error:   "invalid_mfa_code",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to verify code",
+			Error:   err.Error(),
+		})
+	}
+}
+
+// verifySecondFactor 校验验证码或恢复码，通过后记录已使用的时间窗口或恢复码
+// 两者都使用条件更新，同一个验证码并发提交时只有一个能通过
+func verifySecondFactor(db *gorm.DB, user *models.User, code, recoveryCode string) error {
+	switch {
+	case code != "":
+		step, ok := mfa.Validate(user.TOTPSecret, code, user.TOTPLastStep)
+		if !ok {
+			return errInvalidMFACode
+		}
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidMFACode
+		}
+		user.TOTPLastStep = step
+		return nil
+
+	case recoveryCode != "":
+		result := db.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, mfa.HashRecoveryCode(recoveryCode)).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidMFACode
+		}
+		return nil
+
+	default:
+		return errMFACodeRequired
+	}
+}
+
+// replaceRecoveryCodes 删除旧的恢复码并生成新的一组，返回明文
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, hashes, err := mfa.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// mfaRequired 站点是否要求该用户启用两步验证
+func mfaRequired(user *models.User) bool {
+	return user.Role == "admin" &&
+		database.GetBoolSetting(database.GetDB(), models.SettingRequireAdminMFA, false)
+}
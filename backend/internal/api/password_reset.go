@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/loginguard"
+	"techblog-api/backend/internal/mailer"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/passwordreset"
+)
+
+// resetMailTimeout 发送重置邮件的时限
+const resetMailTimeout = time.Minute
+
+type PasswordResetHandler struct {
+	config *config.Config
+	mailer mailer.Mailer
+	guard  *loginguard.Guard
+
+	// sending 正在后台发送的重置邮件，关闭服务时等待其完成
+	sending sync.WaitGroup
+}
+
+func NewPasswordResetHandler(cfg *config.Config, m mailer.Mailer, guard *loginguard.Guard) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		config: cfg,
+		mailer: m,
+		guard:  guard,
+	}
+}
+
+// ForgotPassword 申请找回密码，向账号邮箱发送重置链接
+// 邮箱是否已注册都返回相同的结果，令牌签发和邮件发送都在后台进行，响应时间也不暴露账号是否存在
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var user models.User
+	if err := db.Where("LOWER(email) = LOWER(?) AND active = ?", strings.TrimSpace(req.Email), true).First(&user).Error; err == nil {
+		ip := c.ClientIP()
+		h.sending.Add(1)
+		go func() {
+			defer h.sending.Done()
+			h.sendResetMail(user, ip)
+		}()
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword 用重置链接中的令牌设置新密码，成功后所有设备需要重新登录
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 加密新密码，放在事务外避免长时间持有行锁
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to encrypt password",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, err := passwordreset.Reset(database.GetDB(), strings.TrimSpace(req.Token), string(hashedPassword))
+	if err != nil {
+		switch {
+		case errors.Is(err, passwordreset.ErrInvalidToken):
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Reset link is invalid or has already been used",
+				Error:   "invalid_reset_token",
+			})
+		case errors.Is(err, passwordreset.ErrExpired):
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Reset link has expired, please request a new one",
+				Error:   "reset_token_expired",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to reset password",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	// 密码已更换，之前累计的登录失败不再有意义
+	if err := h.guard.Succeed(user.Username, ""); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Password reset successfully, please log in again",
+	})
+}
+
+// Wait 等待后台发送中的重置邮件全部结束，需在HTTP服务停止接收请求后调用
+func (h *PasswordResetHandler) Wait() {
+	h.sending.Wait()
+}
+
+// sendResetMail 签发重置令牌并发送包含重置链接的邮件，失败只记录日志
+func (h *PasswordResetHandler) sendResetMail(user models.User, ip string) {
+	token, err := passwordreset.Issue(database.GetDB(), user.ID, ip, h.config.PasswordResetTTL)
+	if errors.Is(err, passwordreset.ErrTooSoon) {
+		// 刚发过邮件，不再重复发送
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to issue password reset token for user %d: %v", user.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resetMailTimeout)
+	defer cancel()
+
+	link := strings.TrimRight(h.config.SiteURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("[%s] 重置密码", h.config.SiteTitle),
+		Text: fmt.Sprintf("%s，你好：\n\n"+
+			"我们收到了重置你在%s的账号密码的请求。请在%d分钟内打开下面的链接设置新密码：\n\n"+
+			"%s\n\n"+
+			"链接只能使用一次。重置后所有已登录的设备都需要重新登录。\n"+
+			"如果这不是你本人的操作，请忽略这封邮件，你的密码不会改变。\n",
+			user.Username, h.config.SiteTitle, int(h.config.PasswordResetTTL.Minutes()), link),
+	}
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send password reset mail to user %d: %v", user.ID, err)
+	}
+}
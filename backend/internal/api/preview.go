@@ -0,0 +1,220 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+// errInvalidPreview 预览令牌不存在、已过期或已撤销
+var errInvalidPreview = errors.New("invalid preview token")
+
+type PreviewHandler struct {
+	config *config.Config
+}
+
+func NewPreviewHandler(cfg *config.Config) *PreviewHandler {
+	return &PreviewHandler{
+		config: cfg,
+	}
+}
+
+// CreatePreview 为文章生成预览链接（需要管理员权限）
+func (h *PreviewHandler) CreatePreview(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	var req models.PreviewTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var post models.BlogPost
+
+	if err := db.Select("id, slug").First(&post, postID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found",
+			Error:   "post_not_found",
+		})
+		return
+	}
+
+	token, err := newPreviewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate preview token",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ttl := h.config.PreviewLinkTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Hour
+	}
+
+	preview := models.PreviewToken{
+		PostID:      post.ID,
+		TokenHash:   hashPreviewToken(token),
+		Note:        req.Note,
+		CreatedByID: currentUserID(c),
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := db.Create(&preview).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create preview link",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Preview link created successfully",
+		Data: models.PreviewLink{
+			PreviewToken: preview,
+			Token:        token,
+			URL:          postURL(h.config, post.Slug) + "?preview=" + url.QueryEscape(token),
+		},
+	})
+}
+
+// ListPreviews 获取文章仍然有效的预览链接（需要管理员权限）
+func (h *PreviewHandler) ListPreviews(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var previews []models.PreviewToken
+
+	if err := db.Where("post_id = ? AND revoked_at IS NULL AND expires_at > ?", postID, time.Now()).
+		Order("created_at DESC").
+		Find(&previews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch preview links",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Preview links fetched successfully",
+		Data:    previews,
+	})
+}
+
+// RevokePreview 撤销预览链接（需要管理员权限）
+func (h *PreviewHandler) RevokePreview(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+	previewID, err := strconv.ParseUint(c.Param("previewId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid preview ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	result := db.Model(&models.PreviewToken{}).
+		Where("id = ? AND post_id = ? AND revoked_at IS NULL", previewID, postID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to revoke preview link",
+			Error:   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Preview link not found",
+			Error:   "preview_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Preview link revoked successfully",
+	})
+}
+
+// resolvePreview 校验预览令牌并返回对应的文章ID，同时记录最近使用时间
+func resolvePreview(db *gorm.DB, token string) (uint, error) {
+	var preview models.PreviewToken
+	err := db.Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hashPreviewToken(token), time.Now()).
+		First(&preview).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, errInvalidPreview
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	db.Model(&preview).UpdateColumn("last_used_at", time.Now())
+	return preview.PostID, nil
+}
+
+// newPreviewToken 生成256位随机令牌
+func newPreviewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashPreviewToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
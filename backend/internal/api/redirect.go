@@ -0,0 +1,308 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+type RedirectHandler struct {
+	config *config.Config
+}
+
+func NewRedirectHandler(cfg *config.Config) *RedirectHandler {
+	return &RedirectHandler{
+		config: cfg,
+	}
+}
+
+// Resolve 查询路径对应的重定向目标，供前端在404时调用：?path=/blog/old-slug
+func (h *RedirectHandler) Resolve(c *gin.Context) {
+	target, ok := resolvePath(database.GetDB(), c.Query("path"))
+	if !ok {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "No redirect for this path",
+			Error:   "redirect_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Redirect resolved successfully",
+		Data:    target,
+	})
+}
+
+// Fallback 未匹配路由时查找重定向，找不到则返回404
+func (h *RedirectHandler) Fallback(c *gin.Context) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		if target, ok := resolvePath(database.GetDB(), c.Request.URL.Path); ok {
+			c.Redirect(target.StatusCode, target.Location)
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, models.APIResponse{
+		Success: false,
+		Message: "Resource not found",
+		Error:   "not_found",
+	})
+}
+
+// GetRedirects 获取重定向列表（需要管理员权限）
+func (h *RedirectHandler) GetRedirects(c *gin.Context) {
+	db := database.GetDB()
+	var redirects []models.Redirect
+
+	if err := db.Order("from_path ASC").Find(&redirects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch redirects",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Redirects fetched successfully",
+		Data:    redirects,
+	})
+}
+
+// CreateRedirect 创建重定向（需要管理员权限）
+func (h *RedirectHandler) CreateRedirect(c *gin.Context) {
+	var req models.RedirectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	redirect := models.Redirect{}
+	if !applyRedirectRequest(c, &redirect, req) {
+		return
+	}
+
+	db := database.GetDB()
+	if err := db.Create(&redirect).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Failed to create redirect, path may already exist",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Redirect created successfully",
+		Data:    redirect,
+	})
+}
+
+// UpdateRedirect 更新重定向（需要管理员权限）
+func (h *RedirectHandler) UpdateRedirect(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid redirect ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	var req models.RedirectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var redirect models.Redirect
+
+	if err := db.First(&redirect, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Redirect not found",
+			Error:   "redirect_not_found",
+		})
+		return
+	}
+
+	if !applyRedirectRequest(c, &redirect, req) {
+		return
+	}
+
+	// 命中次数由访问维护，这里不覆盖
+	if err := db.Model(&redirect).Select("from_path", "to_path", "status_code").Updates(&redirect).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Failed to update redirect, path may already exist",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Redirect updated successfully",
+		Data:    redirect,
+	})
+}
+
+// DeleteRedirect 删除重定向（需要管理员权限）
+func (h *RedirectHandler) DeleteRedirect(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid redirect ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	result := db.Delete(&models.Redirect{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete redirect",
+			Error:   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Redirect not found",
+			Error:   "redirect_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Redirect deleted successfully",
+	})
+}
+
+// applyRedirectRequest 校验并填充重定向，失败时已写入响应
+func applyRedirectRequest(c *gin.Context, redirect *models.Redirect, req models.RedirectRequest) bool {
+	from := normalizeRedirectPath(req.FromPath)
+	to := strings.TrimSpace(req.ToPath)
+
+	// 目标只允许站内路径或http(s)地址
+	if !strings.HasPrefix(to, "/") || strings.HasPrefix(to, "//") {
+		if !isHTTPURL(to) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Target must be a site path or an http(s) URL",
+				Error:   "invalid_target",
+			})
+			return false
+		}
+	}
+	if from == normalizeRedirectPath(to) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Redirect target must differ from source",
+			Error:   "redirect_loop",
+		})
+		return false
+	}
+
+	redirect.FromPath = from
+	redirect.ToPath = to
+	redirect.StatusCode = req.StatusCode
+	if redirect.StatusCode == 0 {
+		redirect.StatusCode = http.StatusMovedPermanently
+	}
+	return true
+}
+
+// normalizeRedirectPath 统一路径格式：去掉查询参数和末尾斜杠
+func normalizeRedirectPath(p string) string {
+	p = strings.TrimSpace(p)
+	if u, err := url.Parse(p); err == nil && u.Path != "" {
+		p = u.Path
+	}
+	p = path.Clean("/" + p)
+	return p
+}
+
+// resolveSlugRedirect 查找旧slug对应文章的当前slug，并记录命中
+func resolveSlugRedirect(db *gorm.DB, oldSlug string) (string, bool) {
+	var row struct {
+		ID   uint
+		Slug string
+	}
+	err := db.Model(&models.SlugRedirect{}).
+		Select("slug_redirects.id, blog_posts.slug").
+		Joins("JOIN blog_posts ON blog_posts.id = slug_redirects.post_id AND blog_posts.deleted_at IS NULL").
+		Where("slug_redirects.slug = ?", oldSlug).
+		Scopes(models.Published).
+		Scan(&row).Error
+	if err != nil || row.ID == 0 {
+		return "", false
+	}
+
+	db.Model(&models.SlugRedirect{}).Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+		"hits":        gorm.Expr("hits + 1"),
+		"last_hit_at": time.Now(),
+	})
+	return row.Slug, true
+}
+
+// resolvePath 依次查找管理员配置的重定向和文章旧slug
+func resolvePath(db *gorm.DB, p string) (*models.RedirectTarget, bool) {
+	if strings.TrimSpace(p) == "" {
+		return nil, false
+	}
+	p = normalizeRedirectPath(p)
+
+	var redirect models.Redirect
+	err := db.Where("from_path = ?", p).First(&redirect).Error
+	if err == nil {
+		db.Model(&redirect).UpdateColumns(map[string]interface{}{
+			"hits":        gorm.Expr("hits + 1"),
+			"last_hit_at": time.Now(),
+		})
+		return &models.RedirectTarget{Location: redirect.ToPath, StatusCode: redirect.StatusCode}, true
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false
+	}
+
+	// 文章旧地址 /blog/:slug
+	if oldSlug := strings.TrimPrefix(p, "/blog/"); oldSlug != p && !strings.Contains(oldSlug, "/") {
+		if current, ok := resolveSlugRedirect(db, oldSlug); ok {
+			return &models.RedirectTarget{
+				Location:   "/blog/" + url.PathEscape(current),
+				StatusCode: http.StatusMovedPermanently,
+			}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/diff"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/slug"
+)
+
+type RevisionHandler struct {
+	config *config.Config
+	slugs  *slug.Generator
+}
+
+func NewRevisionHandler(cfg *config.Config) *RevisionHandler {
+	return &RevisionHandler{
+		config: cfg,
+		slugs: slug.New(slug.Options{
+			MaxLength:        cfg.SlugMaxLength,
+			Transliterations: slug.ParseTransliterations(cfg.SlugTransliterations),
+		}),
+	}
+}
+
+// ListRevisions 获取文章的版本列表，不含正文（需要管理员权限）
+func (h *RevisionHandler) ListRevisions(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var revisions []models.PostRevision
+
+	if err := db.Omit("content").Preload("Editor").
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch revisions",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revisions fetched successfully",
+		Data:    revisions,
+	})
+}
+
+// GetRevision 获取单个版本的完整内容（需要管理员权限）
+func (h *RevisionHandler) GetRevision(c *gin.Context) {
+	revision, ok := h.findRevision(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revision fetched successfully",
+		Data:    revision,
+	})
+}
+
+// CompareRevisions 比较两个版本，?from=1&to=3，to省略时与最新版本比较（需要管理员权限）
+func (h *RevisionHandler) CompareRevisions(c *gin.Context) {
+	from, ok := h.findRevision(c, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.findRevision(c, c.DefaultQuery("to", "latest"))
+	if !ok {
+		return
+	}
+
+	lines := diff.Lines(from.Content, to.Content)
+	result := models.RevisionDiff{
+		From:         from.Version,
+		To:           to.Version,
+		TitleChanged: from.Title != to.Title,
+		OldTitle:     from.Title,
+		NewTitle:     to.Title,
+		Changed:      changedRevisionFields(from, to),
+		Stats:        diff.Summarize(lines),
+		Lines:        lines,
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revisions compared successfully",
+		Data:    result,
+	})
+}
+
+// RestoreRevision 将文章恢复为指定版本，并记录为新版本（需要管理员权限）
+// 发布状态不随版本恢复，避免恢复旧内容时意外下线或上线
+func (h *RevisionHandler) RestoreRevision(c *gin.Context) {
+	revision, ok := h.findRevision(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	var post models.BlogPost
+
+	if err := db.First(&post, revision.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found",
+			Error:   "post_not_found",
+		})
+		return
+	}
+
+	previousCategoryID := post.CategoryID
+	post.Title = revision.Title
+	post.Content = revision.Content
+	post.Excerpt = revision.Excerpt
+	post.Author = revision.Author
+	post.CategoryID = revision.CategoryID
+	post.CoverImage = revision.CoverImage
+	post.ReadTime = calculateReadTime(revision.Content)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategory(tx, previousCategoryID); err != nil && !errors.Is(err, errCategoryNotFound) {
+			return err
+		}
+		// 版本引用的分类已被删除时恢复为未分类
+		if err := lockCategory(tx, post.CategoryID); errors.Is(err, errCategoryNotFound) {
+			post.CategoryID = nil
+		} else if err != nil {
+			return err
+		}
+		// 浏览量由计数器原子累加，文章是在事务外读取的，整行保存会用旧值覆盖期间写入的浏览量
+		if err := tx.Omit(clause.Associations, "view_count").Save(&post).Error; err != nil {
+			return err
+		}
+		tags, err := database.ResolveTags(tx, h.slugs, revision.Tags)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&post).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		note := fmt.Sprintf("restored from v%d", revision.Version)
+		if err := recordRevision(tx, h.config, &post, tags, currentUserID(c), note); err != nil {
+			return err
+		}
+		return database.SyncCategoryPostCounts(tx, previousCategoryID, post.CategoryID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to restore revision",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db.Preload("Category").Preload("Tags", orderTags).First(&post, post.ID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revision restored successfully",
+		Data:    post,
+	})
+}
+
+// findRevision 按文章ID和版本号查找版本，version为latest时取最新版本；失败时已写入响应
+func (h *RevisionHandler) findRevision(c *gin.Context, version string) (*models.PostRevision, bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return nil, false
+	}
+
+	db := database.GetDB()
+	query := db.Preload("Editor").Where("post_id = ?", postID)
+	if version == "latest" {
+		query = query.Order("version DESC")
+	} else {
+		n, err := strconv.Atoi(version)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid revision version",
+				Error:   "invalid_version",
+			})
+			return nil, false
+		}
+		query = query.Where("version = ?", n)
+	}
+
+	var revision models.PostRevision
+	if err := query.First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Revision not found",
+			Error:   "revision_not_found",
+		})
+		return nil, false
+	}
+	return &revision, true
+}
+
+// changedRevisionFields 列出标题和正文以外发生变化的字段
+func changedRevisionFields(from, to *models.PostRevision) []string {
+	changed := []string{}
+	if from.Excerpt != to.Excerpt {
+		changed = append(changed, "excerpt")
+	}
+	if from.Author != to.Author {
+		changed = append(changed, "author")
+	}
+	if !sameCategory(from.CategoryID, to.CategoryID) {
+		changed = append(changed, "categoryId")
+	}
+	if fmt.Sprint(from.Tags) != fmt.Sprint(to.Tags) {
+		changed = append(changed, "tags")
+	}
+	if from.CoverImage != to.CoverImage {
+		changed = append(changed, "coverImage")
+	}
+	if from.Published != to.Published {
+		changed = append(changed, "published")
+	}
+	return changed
+}
+
+func sameCategory(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// currentUserID 从JWT中间件设置的上下文中读取当前用户ID
+func currentUserID(c *gin.Context) *uint {
+	if value, exists := c.Get("user_id"); exists {
+		if id, ok := value.(uint); ok {
+			return &id
+		}
+	}
+	return nil
+}
+
+// ensureBaselineRevision 早于版本功能创建的文章在首次修改前，先把当前内容记为基线版本
+// 调用前需已在同一事务中锁住文章行，否则并发保存可能各写一份基线
+func ensureBaselineRevision(tx *gorm.DB, cfg *config.Config, postID uint) error {
+	var count int64
+	if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", postID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var post models.BlogPost
+	if err := tx.Preload("Tags", orderTags).First(&post, postID).Error; err != nil {
+		return err
+	}
+	return recordRevision(tx, cfg, &post, post.Tags, nil, "baseline")
+}
+
+// recordRevision 在事务中为文章写入新版本并按保留策略清理旧版本
+// 调用前文章行应已在同一事务中写入，行锁保证版本号串行递增
+func recordRevision(tx *gorm.DB, cfg *config.Config, post *models.BlogPost, tags []models.Tag, editorID *uint, note string) error {
+	var latest int
+	if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+
+	revision := models.PostRevision{
+		PostID:     post.ID,
+		Version:    latest + 1,
+		Title:      post.Title,
+		Content:    post.Content,
+		Excerpt:    post.Excerpt,
+		Author:     post.Author,
+		CategoryID: post.CategoryID,
+		Tags:       names,
+		CoverImage: post.CoverImage,
+		Published:  post.Published,
+		EditorID:   editorID,
+		Note:       note,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return err
+	}
+
+	return pruneRevisions(tx, cfg, post.ID)
+}
+
+// pruneRevisions 删除既不在最近N个、也不在最近X天内的版本，最新版本始终保留
+func pruneRevisions(tx *gorm.DB, cfg *config.Config, postID uint) error {
+	if cfg.RevisionKeepLast <= 0 && cfg.RevisionKeepDays <= 0 {
+		return nil
+	}
+
+	query := tx.Where("post_id = ?", postID).
+		Where("version < (SELECT MAX(version) FROM post_revisions WHERE post_id = ?)", postID)
+	if cfg.RevisionKeepLast > 0 {
+		query = query.Where("version NOT IN (?)",
+			tx.Model(&models.PostRevision{}).Select("version").
+				Where("post_id = ?", postID).Order("version DESC").Limit(cfg.RevisionKeepLast))
+	}
+	if cfg.RevisionKeepDays > 0 {
+		query = query.Where("created_at < ?", time.Now().AddDate(0, 0, -cfg.RevisionKeepDays))
+	}
+	return query.Delete(&models.PostRevision{}).Error
+}
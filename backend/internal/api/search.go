@@ -0,0 +1,127 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/search"
+)
+
+type SearchHandler struct {
+	config *config.Config
+}
+
+func NewSearchHandler(cfg *config.Config) *SearchHandler {
+	return &SearchHandler{
+		config: cfg,
+	}
+}
+
+// searchRow 全文搜索的原始查询结果
+type searchRow struct {
+	ID              uint
+	Title           string
+	Slug            string
+	Excerpt         string
+	Author          string
+	CoverImage      string
+	CreatedAt       time.Time
+	Rank            float64
+	TitleHighlight  string
+	ContentHeadline string
+}
+
+// Search 全文搜索已发布文章，按相关度排序并返回命中片段
+func (h *SearchHandler) Search(c *gin.Context) {
+	var query models.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > 50 {
+		query.Limit = 10
+	}
+
+	db := database.GetDB()
+	lang := search.NormalizeLanguage(h.config.SearchLanguage)
+	q := search.Query(query.Q)
+
+	dbQuery := search.Match(db.Model(&models.BlogPost{}), lang, q).
+		Scopes(models.Published)
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to search posts",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var rows []searchRow
+	offset := (query.Page - 1) * query.Limit
+	err := dbQuery.
+		Select("blog_posts.id, blog_posts.title, blog_posts.slug, blog_posts.excerpt, blog_posts.author, "+
+			"blog_posts.cover_image, blog_posts.created_at, "+
+			"ts_rank_cd(blog_posts.search_vector, websearch_to_tsquery(?::regconfig, ?)) AS rank, "+
+			search.HeadlineExpr("title")+" AS title_highlight, "+
+			search.HeadlineExpr("content")+" AS content_headline",
+			lang, q,
+			lang, lang, q, search.HeadlineOptions(0),
+			lang, lang, q, search.HeadlineOptions(3)).
+		Order("rank DESC, blog_posts.created_at DESC").
+		Offset(offset).Limit(query.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to search posts",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	results := make([]models.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, models.SearchResult{
+			ID:             row.ID,
+			Title:          row.Title,
+			Slug:           row.Slug,
+			Excerpt:        row.Excerpt,
+			Author:         row.Author,
+			CoverImage:     row.CoverImage,
+			CreatedAt:      row.CreatedAt,
+			Rank:           row.Rank,
+			TitleHighlight: search.Highlight(row.TitleHighlight),
+			Fragments:      search.Fragments(row.ContentHeadline),
+		})
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search completed successfully",
+		Data:    results,
+		Meta: &models.PaginationMeta{
+			Page:       query.Page,
+			Limit:      query.Limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
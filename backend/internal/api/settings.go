@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+type SettingsHandler struct {
+	config *config.Config
+}
+
+func NewSettingsHandler(cfg *config.Config) *SettingsHandler {
+	return &SettingsHandler{
+		config: cfg,
+	}
+}
+
+// GetSecuritySettings 获取安全设置（需要管理员权限）
+func (h *SettingsHandler) GetSecuritySettings(c *gin.Context) {
+	db := database.GetDB()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Security settings fetched successfully",
+		Data: models.SecuritySettings{
+			RequireAdminMFA: database.GetBoolSetting(db, models.SettingRequireAdminMFA, false),
+		},
+	})
+}
+
+// UpdateSecuritySettings 修改安全设置（需要管理员权限）
+// 开启强制两步验证后，未启用两步验证的管理员需要先完成绑定才能继续使用管理接口
+func (h *SettingsHandler) UpdateSecuritySettings(c *gin.Context) {
+	var req models.SecuritySettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db := database.GetDB()
+	if err := database.SetSetting(db, models.SettingRequireAdminMFA, strconv.FormatBool(req.RequireAdminMFA)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update security settings",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Security settings updated successfully",
+		Data:    req,
+	})
+}
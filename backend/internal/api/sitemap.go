@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/sitemap"
+)
+
+type SitemapHandler struct {
+	config *config.Config
+}
+
+func NewSitemapHandler(cfg *config.Config) *SitemapHandler {
+	return &SitemapHandler{
+		config: cfg,
+	}
+}
+
+// Sitemap 输出sitemap.xml（URL较多时为索引文件）
+func (h *SitemapHandler) Sitemap(c *gin.Context) {
+	h.serve(c, sitemap.IndexFile, strings.HasSuffix(c.Request.URL.Path, ".gz"))
+}
+
+// Chunk 输出分片站点地图 /sitemaps/:name，name形如 1.xml 或 1.xml.gz
+func (h *SitemapHandler) Chunk(c *gin.Context) {
+	name := c.Param("name")
+	gzipped := strings.HasSuffix(name, ".gz")
+	h.serve(c, "sitemaps/"+strings.TrimSuffix(name, ".gz"), gzipped)
+}
+
+// Robots 输出robots.txt
+func (h *SitemapHandler) Robots(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.String(http.StatusOK, sitemap.Robots(h.config.SiteURL, h.config.RobotsDisallow))
+}
+
+func (h *SitemapHandler) serve(c *gin.Context, name string, gzipped bool) {
+	if gzipped && !h.config.SitemapGzip {
+		c.String(http.StatusNotFound, "sitemap not found")
+		return
+	}
+
+	urls, err := sitemap.Collect(database.GetDB(), h.config.SiteURL)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to build sitemap")
+		return
+	}
+
+	files, err := sitemap.Files(urls, h.config.SitemapChunkSize, h.config.SiteURL)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to build sitemap")
+		return
+	}
+
+	body, ok := files[name]
+	if !ok {
+		c.String(http.StatusNotFound, "sitemap not found")
+		return
+	}
+
+	etag := fmt.Sprintf(`W/"%x"`, sha1.Sum(body))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=3600")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if gzipped {
+		compressed, err := sitemap.Gzip(body)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "failed to build sitemap")
+			return
+		}
+		c.Data(http.StatusOK, "application/gzip", compressed)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
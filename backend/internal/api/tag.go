@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+type TagHandler struct{}
+
+func NewTagHandler() *TagHandler {
+	return &TagHandler{}
+}
+
+// GetTags 标签云：返回有已发布文章的标签及文章数
+func (h *TagHandler) GetTags(c *gin.Context) {
+	db := database.GetDB()
+	var tags []models.TagCloudItem
+
+	err := db.Table("tags").
+		Select("tags.id, tags.name, tags.slug, COUNT(blog_posts.id) AS count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN blog_posts ON blog_posts.id = post_tags.blog_post_id").
+		Scopes(models.Published).
+		Where("blog_posts.deleted_at IS NULL").
+		Group("tags.id, tags.name, tags.slug").
+		Order("count DESC, tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch tags",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Tags fetched successfully",
+		Data:    tags,
+	})
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+// GetTrash 获取回收站中的文章（需要管理员权限）
+func (h *BlogHandler) GetTrash(c *gin.Context) {
+	var query models.BlogPostQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	h.listPosts(c, query, models.Trashed)
+}
+
+// RestorePost 从回收站恢复文章，slug已被其他文章占用时重新生成（需要管理员权限）
+func (h *BlogHandler) RestorePost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	var post models.BlogPost
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(models.Trashed).First(&post, id).Error; err != nil {
+			return err
+		}
+		// 原分类已删除时恢复为未分类
+		if err := lockCategory(tx, post.CategoryID); errors.Is(err, errCategoryNotFound) {
+			post.CategoryID = nil
+		} else if err != nil {
+			return err
+		}
+
+		slug, err := h.uniqueSlug(tx, post.Slug, post.ID)
+		if err != nil {
+			return err
+		}
+		if err := changeSlug(tx, &post, slug); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&models.BlogPost{}).Where("id = ?", post.ID).
+			UpdateColumns(map[string]interface{}{
+				"deleted_at":  nil,
+				"slug":        post.Slug,
+				"category_id": post.CategoryID,
+			}).Error; err != nil {
+			return err
+		}
+		return database.SyncCategoryPostCounts(tx, post.CategoryID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found in trash",
+			Error:   "post_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to restore post",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	db.Preload("Category").Preload("Tags", orderTags).First(&post, post.ID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Post restored successfully",
+		Data:    post,
+	})
+}
+
+// PurgePost 永久删除回收站中的文章及其评论、版本等数据（需要管理员权限）
+func (h *BlogHandler) PurgePost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	db := database.GetDB()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 只允许清除已在回收站中的文章，避免误删线上文章
+		var post models.BlogPost
+		if err := tx.Scopes(models.Trashed).Select("blog_posts.id").First(&post, id).Error; err != nil {
+			return err
+		}
+		return database.PurgePost(tx, post.ID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Post not found in trash",
+			Error:   "post_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to purge post",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Post permanently deleted",
+	})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+// GetPostViews 获取文章最近N天的每日浏览量，?days=30，最多365天（需要管理员权限）
+func (h *BlogHandler) GetPostViews(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid post ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "days must be between 1 and 365",
+			Error:   "invalid_days",
+		})
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	db := database.GetDB()
+	var rows []models.PostDailyView
+	if err := db.Where("post_id = ? AND day >= ?", postID, since).
+		Order("day ASC").
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch post views",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Post views fetched successfully",
+		Data:    fillDailyViews(rows, since, today),
+	})
+}
+
+// fillDailyViews 补齐没有访问的日期，保证图表连续
+func fillDailyViews(rows []models.PostDailyView, since, until time.Time) []models.DailyViews {
+	byDay := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		byDay[row.Day.UTC().Format("2006-01-02")] += row.Views
+	}
+
+	var series []models.DailyViews
+	for day := since; !day.After(until); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		series = append(series, models.DailyViews{Day: key, Views: byDay[key]})
+	}
+	return series
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 	"log"
 	"github.com/joho/godotenv"
 )
@@ -11,6 +13,8 @@ type Config struct {
 	// 服务器配置
 	ServerPort string
 	ServerHost string
+	// 可信的反向代理地址或网段，只有来自这些地址的X-Forwarded-For才会被采信；为空时直接使用连接地址
+	TrustedProxies []string
 	
 	// 数据库配置
 	DBHost     string
@@ -21,19 +25,108 @@ type Config struct {
 	DBSSLMode  string
 	
 	// JWT配置
-	JWTSecret string
+	JWTSecret       string
+	AccessTokenTTL  time.Duration // 访问令牌有效期
+	RefreshTokenTTL time.Duration // 刷新令牌有效期，每次刷新重新计算
+	MFAChallengeTTL time.Duration // 两步登录中提交验证码的时限
+	
+	// 登录失败限制
+	LoginMaxFailures      int           // 同一用户名连续失败多少次后锁定
+	LoginMaxFailuresPerIP int           // 同一IP连续失败多少次后锁定
+	LoginLockoutDuration  time.Duration // 锁定时长
+	LoginBackoffBase      time.Duration // 失败后的等待时间，每次失败翻倍
+	LoginFailureWindow    time.Duration // 超过这么久没有失败，计数重新开始
+	
+	// 找回密码
+	PasswordResetTTL time.Duration // 重置链接有效期
+	
+	// 邮件配置
+	MailDriver   string // smtp、file或log，file和log只用于开发环境，生产环境拒绝启动
+	MailFrom     string // 发件人，如 "TechBlog <noreply@example.com>"
+	MailDir      string // file方式保存邮件的目录
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTLS      string // starttls、tls或none
 	
 	// 文件上传配置
 	UploadPath string
 	MaxFileSize int64
 	
+	// 文件存储配置
+	StorageDriver string        // local或s3，多副本部署时使用s3
+	S3Endpoint    string        // 不含协议，如s3.amazonaws.com、localhost:9000
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3UseSSL      bool
+	S3PathStyle   bool          // 使用路径形式访问桶，MinIO通常需要开启
+	S3PublicURL   string        // 桶可公开读取时的访问前缀（如CDN），为空时使用签名地址
+	S3URLExpiry   time.Duration // 签名地址有效期
+	
+	// 图片缩略图配置
+	ImageVariantWidths []int // 允许的缩略图宽度
+	ImageMaxPixels     int   // 超过该像素数的原图不生成缩略图，避免解码时占用过多内存
+	
 	// CORS配置
 	AllowOrigins []string
 	
+	// 搜索配置
+	SearchLanguage string // PostgreSQL全文检索配置，如simple、english
+	
+	// 站点配置（用于订阅源、站点地图中的绝对链接）
+	SiteURL         string
+	SiteTitle       string
+	SiteDescription string
+	SiteLanguage    string
+	
+	// 订阅源配置
+	FeedFullContent bool // true输出全文，false只输出摘要
+	FeedItemLimit   int
+	
+	// 站点地图与robots.txt配置
+	SitemapChunkSize int      // 单个站点地图最多包含的URL数，超出后生成索引
+	SitemapGzip      bool     // 是否提供.xml.gz压缩版本
+	RobotsDisallow   []string // robots.txt中禁止抓取的路径
+	
+	// 评论配置
+	CommentMaxDepth int // 回复的最大嵌套深度
+	
+	// 文章版本保留策略，两者都为0时保留全部版本
+	RevisionKeepLast int // 保留最近N个版本
+	RevisionKeepDays int // 保留最近X天内的全部版本
+	
+	// 定时发布检查间隔
+	PublishCheckInterval time.Duration
+	
+	// 回收站保留时长，超过后自动永久删除，0表示不自动清理
+	TrashRetention time.Duration
+	
+	// 草稿预览链接默认有效期
+	PreviewLinkTTL time.Duration
+	
+	// 浏览量统计
+	ViewDedupWindow   time.Duration // 同一访客在窗口内重复访问只计一次
+	ViewFlushInterval time.Duration // 内存计数写入数据库的间隔
+	
+	// 访问分析
+	GeoIPDBPath            string        // 本地MaxMind格式的GeoIP数据库，文件不存在时不统计国家
+	AnalyticsFlushInterval time.Duration // 内存聚合写入数据库的间隔
+	AnalyticsRetentionDays int           // 每日聚合数据保留天数，0表示一直保留
+	
+	// Slug配置
+	SlugMaxLength        int
+	SlugTransliterations string // 自定义音译表，如 "ä=ae,ö=oe,ß=ss"
+	
 	// 环境
 	Environment string
 }
 
+// minSlugMaxLength SLUG_MAX_LENGTH允许的最小值，与slug.MinMaxLength一致
+const minSlugMaxLength = 8
+
 func LoadConfig() *Config {
 	// 加载.env文件（如果存在）
 	if err := godotenv.Load(); err != nil {
@@ -44,6 +137,7 @@ func LoadConfig() *Config {
 		// 服务器配置
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		ServerHost: getEnv("SERVER_HOST", "0.0.0.0"),
+		TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", nil),
 		
 		// 数据库配置
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -54,12 +148,51 @@ func LoadConfig() *Config {
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		
 		// JWT配置
-		JWTSecret: getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
+		JWTSecret:       getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
+		AccessTokenTTL:  getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		MFAChallengeTTL: getEnvAsDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		
+		// 登录失败限制
+		LoginMaxFailures:      getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxFailuresPerIP: getEnvAsInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginLockoutDuration:  getEnvAsDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginBackoffBase:      getEnvAsDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginFailureWindow:    getEnvAsDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+		
+		// 找回密码
+		PasswordResetTTL: getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+		
+		// 邮件配置
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "TechBlog <noreply@localhost>"),
+		MailDir:      getEnv("MAIL_DIR", "./data/mail"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPTLS:      getEnv("SMTP_TLS", "starttls"),
 		
 		// 文件上传配置
 		UploadPath:  getEnv("UPLOAD_PATH", "./uploads"),
 		MaxFileSize: getEnvAsInt64("MAX_FILE_SIZE", 10*1024*1024), // 10MB默认
 		
+		// 文件存储配置
+		StorageDriver: getEnv("STORAGE_DRIVER", "local"),
+		S3Endpoint:    getEnv("S3_ENDPOINT", ""),
+		S3Region:      getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:      getEnv("S3_BUCKET", ""),
+		S3AccessKey:   getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:   getEnv("S3_SECRET_KEY", ""),
+		S3UseSSL:      getEnvAsBool("S3_USE_SSL", true),
+		S3PathStyle:   getEnvAsBool("S3_PATH_STYLE", false),
+		S3PublicURL:   getEnv("S3_PUBLIC_URL", ""),
+		S3URLExpiry:   getEnvAsDuration("S3_URL_EXPIRY", time.Hour),
+		
+		// 图片缩略图配置
+		ImageVariantWidths: getEnvAsIntSlice("IMAGE_VARIANT_WIDTHS", []int{320, 640, 1280}),
+		ImageMaxPixels:     getEnvAsInt("IMAGE_MAX_PIXELS", 24000000),
+		
 		// CORS配置
 		AllowOrigins: []string{
 			getEnv("FRONTEND_URL", "http://localhost:5173"),
@@ -67,10 +200,63 @@ func LoadConfig() *Config {
 			"http://18.178.203.0",
 		},
 		
+		// 搜索配置
+		SearchLanguage: getEnv("SEARCH_LANGUAGE", "simple"),
+		
+		// 站点配置
+		SiteURL:         getEnv("SITE_URL", getEnv("FRONTEND_URL", "http://localhost:5173")),
+		SiteTitle:       getEnv("SITE_TITLE", "TechBlog"),
+		SiteDescription: getEnv("SITE_DESCRIPTION", "前沿技术分享、人工智能与后端开发"),
+		SiteLanguage:    getEnv("SITE_LANGUAGE", "zh-CN"),
+		
+		// 订阅源配置
+		FeedFullContent: getEnvAsBool("FEED_FULL_CONTENT", false),
+		FeedItemLimit:   getEnvAsInt("FEED_ITEM_LIMIT", 20),
+		
+		// 站点地图与robots.txt配置
+		SitemapChunkSize: getEnvAsInt("SITEMAP_CHUNK_SIZE", 50000),
+		SitemapGzip:      getEnvAsBool("SITEMAP_GZIP", true),
+		RobotsDisallow:   getEnvAsSlice("ROBOTS_DISALLOW", []string{"/api/"}),
+		
+		// 评论配置
+		CommentMaxDepth: getEnvAsInt("COMMENT_MAX_DEPTH", 3),
+		
+		// 文章版本保留策略
+		RevisionKeepLast: getEnvAsInt("REVISION_KEEP_LAST", 50),
+		RevisionKeepDays: getEnvAsInt("REVISION_KEEP_DAYS", 0),
+		
+		// 定时发布检查间隔
+		PublishCheckInterval: getEnvAsDuration("PUBLISH_CHECK_INTERVAL", 30*time.Second),
+		
+		// 回收站保留时长
+		TrashRetention: getEnvAsDuration("TRASH_RETENTION", 30*24*time.Hour),
+		
+		// 草稿预览链接默认有效期
+		PreviewLinkTTL: getEnvAsDuration("PREVIEW_LINK_TTL", 72*time.Hour),
+		
+		// 浏览量统计
+		ViewDedupWindow:   getEnvAsDuration("VIEW_DEDUP_WINDOW", 30*time.Minute),
+		ViewFlushInterval: getEnvAsDuration("VIEW_FLUSH_INTERVAL", 10*time.Second),
+		
+		// 访问分析
+		GeoIPDBPath:            getEnv("GEOIP_DB_PATH", "./data/GeoLite2-Country.mmdb"),
+		AnalyticsFlushInterval: getEnvAsDuration("ANALYTICS_FLUSH_INTERVAL", 30*time.Second),
+		AnalyticsRetentionDays: getEnvAsInt("ANALYTICS_RETENTION_DAYS", 400),
+		
+		// Slug配置
+		SlugMaxLength:        getEnvAsInt("SLUG_MAX_LENGTH", 80),
+		SlugTransliterations: getEnv("SLUG_TRANSLITERATIONS", ""),
+		
 		// 环境
 		Environment: getEnv("ENVIRONMENT", "development"),
 	}
 	
+	// slug需要容纳-2、-999这样的冲突后缀，过短时按最小值处理
+	if config.SlugMaxLength < minSlugMaxLength {
+		log.Printf("SLUG_MAX_LENGTH=%d is too small, using %d", config.SlugMaxLength, minSlugMaxLength)
+		config.SlugMaxLength = minSlugMaxLength
+	}
+	
 	return config
 }
 
@@ -81,6 +267,57 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvAsIntSlice(key string, defaultValue []int) []int {
+	var values []int
+	for _, value := range getEnvAsSlice(key, nil) {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			values = append(values, n)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.Atoi(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsInt64(key string, defaultValue int64) int64 {
 	valueStr := getEnv(key, "")
 	if value, err := strconv.ParseInt(valueStr, 10, 64); err == nil {
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SyncCategoryPostCounts 重新统计分类下公开可见且未删除的文章数
+func SyncCategoryPostCounts(tx *gorm.DB, categoryIDs ...*uint) error {
+	var ids []uint
+	for _, id := range categoryIDs {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return tx.Exec(`UPDATE categories SET post_count = (
+		SELECT COUNT(*) FROM blog_posts
+		WHERE blog_posts.category_id = categories.id
+			AND blog_posts.published = true
+			AND (blog_posts.publish_at IS NULL OR blog_posts.publish_at <= ?)
+			AND blog_posts.deleted_at IS NULL
+	) WHERE id IN ?`, time.Now(), ids).Error
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"log"
 	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/markdown"
 	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/search"
+	"techblog-api/backend/internal/slug"
+	"techblog-api/backend/internal/textseg"
 	
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,7 +17,8 @@ import (
 
 var DB *gorm.DB
 
-func InitDatabase(config *config.Config) {
+// Connect 只建立数据库连接，不迁移表结构也不创建默认数据，供只读的命令行工具使用
+func Connect(config *config.Config) error {
 	var err error
 	
 	// 构建数据库连接字符串
@@ -37,16 +42,57 @@ func InitDatabase(config *config.Config) {
 	
 	// 连接数据库
 	DB, err = gorm.Open(postgres.Open(dsn), gormConfig)
-	if err != nil {
+	return err
+}
+
+func InitDatabase(config *config.Config) {
+	if err := Connect(config); err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	
 	log.Println("Database connected successfully")
 	
+	if err := Migrate(config); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+}
+
+// Migrate 迁移表结构、索引和全文检索触发器，并为历史数据补齐派生字段
+func Migrate(config *config.Config) error {
 	// 自动迁移数据库结构
 	if err := AutoMigrate(); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		return err
+	}
+	
+	// 标签索引及旧JSON标签迁移
+	tagSlugs := slug.New(slug.Options{
+		MaxLength:        config.SlugMaxLength,
+		Transliterations: slug.ParseTransliterations(config.SlugTransliterations),
+	})
+	if err := migrateTags(tagSlugs); err != nil {
+		return fmt.Errorf("failed to migrate tags: %w", err)
+	}
+	
+	// 旧的slug唯一索引包含回收站中的文章，替换为只约束未删除文章的部分索引
+	if err := DB.Exec("DROP INDEX IF EXISTS idx_blog_posts_slug").Error; err != nil {
+		return fmt.Errorf("failed to migrate post slug index: %w", err)
+	}
+	
+	// 全文检索触发器与索引
+	if err := search.Migrate(DB, config.SearchLanguage); err != nil {
+		return err
+	}
+	
+	// 为历史文章补齐中文分词
+	if err := backfillSearchTokens(); err != nil {
+		log.Printf("Warning: Failed to backfill search tokens: %v", err)
+	}
+	
+	// 为历史文章渲染正文HTML和目录
+	if err := backfillRenderedContent(); err != nil {
+		log.Printf("Warning: Failed to render post content: %v", err)
 	}
+	return nil
 }
 
 func AutoMigrate() error {
@@ -54,10 +100,28 @@ func AutoMigrate() error {
 	
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RecoveryCode{},
+		&models.PasswordResetToken{},
+		&models.LoginThrottle{},
+		&models.LoginLockout{},
+		&models.LoginChallenge{},
+		&models.Setting{},
 		&models.BlogPost{},
 		&models.ContactMessage{},
 		&models.Category{},
+		&models.Tag{},
 		&models.Comment{},
+		&models.PostRevision{},
+		&models.PreviewToken{},
+		&models.SlugRedirect{},
+		&models.Redirect{},
+		&models.PostDailyView{},
+		&models.AnalyticsSiteDay{},
+		&models.AnalyticsPageDay{},
+		&models.AnalyticsBreakdownDay{},
+		&models.Media{},
 		&models.SponsorOrder{},
 	)
 	
@@ -65,6 +129,13 @@ func AutoMigrate() error {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 	
+	// 早期评论只有approved字段，补齐审核状态
+	if err := DB.Model(&models.Comment{}).
+		Where("approved = ? AND status = ?", true, models.CommentPending).
+		Update("status", models.CommentApproved).Error; err != nil {
+		return fmt.Errorf("comment status migration failed: %w", err)
+	}
+	
 	log.Println("Database migration completed successfully")
 	
 	// 创建默认管理员用户（如果不存在）
@@ -107,6 +178,56 @@ func CreateDefaultAdmin() error {
 	return nil
 }
 
+// backfillSearchTokens 为分词字段为空或分词规则已更新的文章重新生成分词，不修改更新时间
+func backfillSearchTokens() error {
+	var posts []models.BlogPost
+	if err := DB.Unscoped().Where("search_title IS NULL OR search_version < ?", textseg.Version).Find(&posts).Error; err != nil {
+		return err
+	}
+	
+	for i := range posts {
+		post := &posts[i]
+		post.BeforeSave(DB)
+		if err := DB.Unscoped().Model(post).UpdateColumns(map[string]interface{}{
+			"search_title":   post.SearchTitle,
+			"search_excerpt": post.SearchExcerpt,
+			"search_content": post.SearchContent,
+			"search_version": post.SearchVersion,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	
+	if len(posts) > 0 {
+		log.Printf("Backfilled search tokens for %d posts", len(posts))
+	}
+	return nil
+}
+
+// backfillRenderedContent 为尚未渲染的文章生成正文HTML和目录，不修改更新时间
+func backfillRenderedContent() error {
+	var posts []models.BlogPost
+	if err := DB.Unscoped().Select("id, content").Where("content_html IS NULL OR toc IS NULL").Find(&posts).Error; err != nil {
+		return err
+	}
+	
+	for _, post := range posts {
+		doc, err := markdown.Render(post.Content)
+		if err != nil {
+			return fmt.Errorf("post %d: %w", post.ID, err)
+		}
+		if err := DB.Unscoped().Model(&models.BlogPost{}).Where("id = ?", post.ID).
+			Select("content_html", "toc").UpdateColumns(&models.BlogPost{ContentHTML: doc.HTML, TOC: doc.TOC}).Error; err != nil {
+			return err
+		}
+	}
+	
+	if len(posts) > 0 {
+		log.Printf("Rendered content for %d posts", len(posts))
+	}
+	return nil
+}
+
 func GetDB() *gorm.DB {
 	return DB
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/models"
+)
+
+// PurgePost 在事务中永久删除文章及其标签关联、评论、版本、预览链接和旧slug
+func PurgePost(tx *gorm.DB, postID uint) error {
+	if err := tx.Exec("DELETE FROM post_tags WHERE blog_post_id = ?", postID).Error; err != nil {
+		return err
+	}
+	for _, dependent := range []interface{}{
+		&models.Comment{},
+		&models.PostRevision{},
+		&models.PreviewToken{},
+		&models.SlugRedirect{},
+		&models.PostDailyView{},
+	} {
+		if err := tx.Where("post_id = ?", postID).Delete(dependent).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Unscoped().Delete(&models.BlogPost{}, postID).Error
+}
+
+// PurgeTrash 永久删除在before之前移入回收站的文章，返回删除数量
+func PurgeTrash(tx *gorm.DB, before time.Time) (int, error) {
+	var ids []uint
+	if err := tx.Model(&models.BlogPost{}).Scopes(models.Trashed).
+		Where("blog_posts.deleted_at < ?", before).
+		Pluck("blog_posts.id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := PurgePost(tx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
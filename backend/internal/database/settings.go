@@ -0,0 +1,77 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+)
+
+// settingsCacheTTL 设置读取频繁而很少修改，缓存一段时间；多实例部署时其他实例最多延迟这么久生效
+const settingsCacheTTL = 30 * time.Second
+
+type cachedSetting struct {
+	value    string
+	found    bool
+	loadedAt time.Time
+}
+
+var (
+	settingsMu    sync.Mutex
+	settingsCache = map[string]cachedSetting{}
+)
+
+// GetSetting 读取设置，不存在时found为false
+func GetSetting(tx *gorm.DB, key string) (value string, found bool, err error) {
+	settingsMu.Lock()
+	cached, ok := settingsCache[key]
+	settingsMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < settingsCacheTTL {
+		return cached.value, cached.found, nil
+	}
+
+	var setting models.Setting
+	err = tx.Where("key = ?", key).First(&setting).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, err
+	}
+	found = err == nil
+
+	settingsMu.Lock()
+	settingsCache[key] = cachedSetting{value: setting.Value, found: found, loadedAt: time.Now()}
+	settingsMu.Unlock()
+	return setting.Value, found, nil
+}
+
+// GetBoolSetting 读取布尔设置，不存在或读取失败时返回默认值
+func GetBoolSetting(tx *gorm.DB, key string, defaultValue bool) bool {
+	value, found, err := GetSetting(tx, key)
+	if err != nil || !found {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// SetSetting 写入设置并更新本实例的缓存
+func SetSetting(tx *gorm.DB, key, value string) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&models.Setting{Key: key, Value: value}).Error
+	if err != nil {
+		return err
+	}
+
+	settingsMu.Lock()
+	settingsCache[key] = cachedSetting{value: value, found: true, loadedAt: time.Now()}
+	settingsMu.Unlock()
+	return nil
+}
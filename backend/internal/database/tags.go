@@ -0,0 +1,147 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/slug"
+)
+
+// maxTagNameLength 标签名称的最大字符数，与models.Tag.Name的列长度一致
+const maxTagNameLength = 50
+
+// NormalizeTagNames 去除空白并按不区分大小写去重，保留首次出现的写法
+func NormalizeTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// ResolveTags 按名称查找标签，不存在时创建，名称比较不区分大小写
+func ResolveTags(tx *gorm.DB, slugs *slug.Generator, names []string) ([]models.Tag, error) {
+	names = NormalizeTagNames(names)
+	tags := make([]models.Tag, 0, len(names))
+
+	for _, name := range names {
+		var tag models.Tag
+		err := tx.Where("lower(name) = lower(?)", name).First(&tag).Error
+		if err == nil {
+			tags = append(tags, tag)
+			continue
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+
+		tagSlug, err := slugs.Unique(slugs.Make(name), func(candidate string) (bool, error) {
+			var count int64
+			err := tx.Model(&models.Tag{}).Where("slug = ?", candidate).Count(&count).Error
+			return count > 0, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// 并发创建同名标签时忽略冲突，再按名称读取
+		tag = models.Tag{Name: name, Slug: tagSlug}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+			return nil, err
+		}
+		if tag.ID == 0 {
+			if err := tx.Where("lower(name) = lower(?)", name).First(&tag).Error; err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// migrateTags 创建标签名称的不区分大小写唯一索引，并迁移旧的JSON标签列
+func migrateTags(slugs *slug.Generator) error {
+	if err := DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name_lower ON tags (lower(name))").Error; err != nil {
+		return fmt.Errorf("failed to create tag name index: %w", err)
+	}
+
+	if !DB.Migrator().HasColumn("blog_posts", "tags") {
+		return nil
+	}
+
+	var legacy []struct {
+		ID   uint
+		Tags string
+	}
+	if err := DB.Table("blog_posts").Select("id, tags").Scan(&legacy).Error; err != nil {
+		return fmt.Errorf("failed to read legacy tags: %w", err)
+	}
+
+	// 无法解析或标签名称超长的行不丢弃，保留旧列改名为tags_legacy，供人工处理
+	var invalid []uint
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, post := range legacy {
+			var names []string
+			if post.Tags != "" {
+				if err := json.Unmarshal([]byte(post.Tags), &names); err != nil {
+					log.Printf("Warning: invalid legacy tags on post %d: %v", post.ID, err)
+					invalid = append(invalid, post.ID)
+					continue
+				}
+			}
+			if name, ok := overlongTagName(names); ok {
+				log.Printf("Warning: legacy tag %q on post %d exceeds %d characters", name, post.ID, maxTagNameLength)
+				invalid = append(invalid, post.ID)
+				continue
+			}
+
+			tags, err := ResolveTags(tx, slugs, names)
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				if err := tx.Exec("INSERT INTO post_tags (blog_post_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+					post.ID, tag.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(invalid) > 0 {
+			return tx.Exec("ALTER TABLE blog_posts RENAME COLUMN tags TO tags_legacy").Error
+		}
+		return tx.Exec("ALTER TABLE blog_posts DROP COLUMN tags").Error
+	})
+	if err != nil {
+		return fmt.Errorf("legacy tag migration failed: %w", err)
+	}
+
+	if len(invalid) > 0 {
+		log.Printf("Warning: legacy tags of posts %v could not be migrated, original values kept in blog_posts.tags_legacy", invalid)
+	}
+	log.Printf("Migrated legacy JSON tags for %d posts", len(legacy))
+	return nil
+}
+
+// overlongTagName 返回第一个超过maxTagNameLength的标签名称
+func overlongTagName(names []string) (string, bool) {
+	for _, name := range names {
+		if utf8.RuneCountInString(strings.TrimSpace(name)) > maxTagNameLength {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,201 @@
+/*
+开发心理过程：
+1. 文章版本对比只需要行级差异，使用Myers算法求最短编辑脚本
+2. 先去掉公共前缀和后缀，常见的小改动只需要处理很少的行
+3. 输出每一行的操作和新旧行号，前端可以直接渲染成左右或统一视图
+4. 不保存每一步的V数组，而是从两端同时搜索找到中间点后分治，内存只和文章长度线性相关
+*/
+
+package diff
+
+import "strings"
+
+// 行操作类型
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Line 差异中的一行，OldLine/NewLine从1开始，不存在时为0
+type Line struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine,omitempty"`
+	NewLine int    `json:"newLine,omitempty"`
+}
+
+// Stats 差异统计
+type Stats struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// Lines 比较两段文本的行级差异
+func Lines(a, b string) []Line {
+	return Compare(splitLines(a), splitLines(b))
+}
+
+// Summarize 统计新增和删除的行数
+func Summarize(lines []Line) Stats {
+	var s Stats
+	for _, l := range lines {
+		switch l.Op {
+		case OpInsert:
+			s.Added++
+		case OpDelete:
+			s.Removed++
+		}
+	}
+	return s
+}
+
+// Compare 比较两组行
+func Compare(a, b []string) []Line {
+	result := make([]Line, 0, len(a)+len(b))
+	compare(a, b, 0, 0, &result)
+	return result
+}
+
+// compare 比较a和b并把结果追加到out，aOff/bOff是a、b在原文中的起始下标
+func compare(a, b []string, aOff, bOff int, out *[]Line) {
+	// 公共前缀
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	// 公共后缀
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	equal(a[:prefix], aOff, bOff, out)
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	aStart, bStart := aOff+prefix, bOff+prefix
+
+	switch {
+	case len(middleA) == 0:
+		for i, text := range middleB {
+			*out = append(*out, Line{Op: OpInsert, Text: text, NewLine: bStart + i + 1})
+		}
+	case len(middleB) == 0:
+		for i, text := range middleA {
+			*out = append(*out, Line{Op: OpDelete, Text: text, OldLine: aStart + i + 1})
+		}
+	default:
+		if x, y, ok := bisect(middleA, middleB); ok {
+			compare(middleA[:x], middleB[:y], aStart, bStart, out)
+			compare(middleA[x:], middleB[y:], aStart+x, bStart+y, out)
+		} else {
+			// 没有任何相同的行
+			for i, text := range middleA {
+				*out = append(*out, Line{Op: OpDelete, Text: text, OldLine: aStart + i + 1})
+			}
+			for i, text := range middleB {
+				*out = append(*out, Line{Op: OpInsert, Text: text, NewLine: bStart + i + 1})
+			}
+		}
+	}
+
+	equal(a[len(a)-suffix:], aOff+len(a)-suffix, bOff+len(b)-suffix, out)
+}
+
+// equal 把相同的行追加到out
+func equal(lines []string, aOff, bOff int, out *[]Line) {
+	for i, text := range lines {
+		*out = append(*out, Line{Op: OpEqual, Text: text, OldLine: aOff + i + 1, NewLine: bOff + i + 1})
+	}
+}
+
+// bisect 从起点和终点同时按Myers算法搜索，两个方向的路径重叠时返回分割点
+// a、b都不为空且首尾行不同；找不到分割点说明两者没有相同的行
+func bisect(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	// 总长度差为奇数时由正向搜索检查重叠，否则由反向搜索检查
+	front := delta%2 != 0
+	// 超出编辑图边界的对角线不再继续搜索
+	kStart1, kEnd1, kStart2, kEnd2 := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + kStart1; k <= d-kEnd1; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				kEnd1 += 2
+			case y > m:
+				kStart1 += 2
+			case front:
+				j := offset + delta - k
+				if j >= 0 && j < size && backward[j] != -1 && x >= n-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + kStart2; k <= d-kEnd2; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > n:
+				kEnd2 += 2
+			case y > m:
+				kStart2 += 2
+			case !front:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					fy := offset + fx - j
+					if fx >= n-x {
+						return fx, fy, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
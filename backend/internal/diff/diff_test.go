@@ -0,0 +1,160 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"both empty", "", "", []Line{}},
+		{"identical", "a\nb\n", "a\nb", []Line{
+			{Op: OpEqual, Text: "a", OldLine: 1, NewLine: 1},
+			{Op: OpEqual, Text: "b", OldLine: 2, NewLine: 2},
+		}},
+		{"insert into empty", "", "x\ny", []Line{
+			{Op: OpInsert, Text: "x", NewLine: 1},
+			{Op: OpInsert, Text: "y", NewLine: 2},
+		}},
+		{"delete everything", "x\ny", "", []Line{
+			{Op: OpDelete, Text: "x", OldLine: 1},
+			{Op: OpDelete, Text: "y", OldLine: 2},
+		}},
+		{"change middle line", "a\nb\nc", "a\nB\nc", []Line{
+			{Op: OpEqual, Text: "a", OldLine: 1, NewLine: 1},
+			{Op: OpDelete, Text: "b", OldLine: 2},
+			{Op: OpInsert, Text: "B", NewLine: 2},
+			{Op: OpEqual, Text: "c", OldLine: 3, NewLine: 3},
+		}},
+		{"crlf is normalized", "a\r\nb\r\n", "a\nb\n", []Line{
+			{Op: OpEqual, Text: "a", OldLine: 1, NewLine: 1},
+			{Op: OpEqual, Text: "b", OldLine: 2, NewLine: 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	got := Summarize(Lines("a\nb\nc\nd", "a\nx\nc\ny\nz"))
+	if want := (Stats{Added: 3, Removed: 2}); got != want {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareIsMinimal(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"abcabba", "cbabac"},
+		{"abcdef", "fedcba"},
+		{"xaxbxcx", "abc"},
+		{"aaaa", "aa"},
+		{"abc", "xyz"},
+		{"the quick brown fox", "the slow brown dog"},
+	}
+	for _, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		checkDiff(t, a, b)
+	}
+
+	// 随机比较，字符集越小公共子序列越多，覆盖各种分割情况
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		alphabet := 1 + i%6
+		checkDiff(t, randomLines(r, alphabet), randomLines(r, alphabet))
+	}
+}
+
+func TestCompareLargeInput(t *testing.T) {
+	// 完全不同的长文本，编辑距离达到上限
+	a := make([]string, 3000)
+	b := make([]string, 3000)
+	for i := range a {
+		a[i] = "old " + strings.Repeat("x", i%7)
+		b[i] = "new " + strings.Repeat("y", i%5)
+	}
+	checkDiff(t, a, b)
+}
+
+// checkDiff 校验差异能分别还原出新旧两版、行号连续，且修改行数等于最短编辑距离
+func checkDiff(t *testing.T, a, b []string) {
+	t.Helper()
+
+	lines := Compare(a, b)
+	var oldText, newText []string
+	oldLine, newLine := 0, 0
+	for _, l := range lines {
+		switch l.Op {
+		case OpEqual:
+			oldLine++
+			newLine++
+			if l.OldLine != oldLine || l.NewLine != newLine {
+				t.Fatalf("equal line numbers %d/%d, want %d/%d", l.OldLine, l.NewLine, oldLine, newLine)
+			}
+			oldText = append(oldText, l.Text)
+			newText = append(newText, l.Text)
+		case OpDelete:
+			oldLine++
+			if l.OldLine != oldLine || l.NewLine != 0 {
+				t.Fatalf("delete line numbers %d/%d, want %d/0", l.OldLine, l.NewLine, oldLine)
+			}
+			oldText = append(oldText, l.Text)
+		case OpInsert:
+			newLine++
+			if l.NewLine != newLine || l.OldLine != 0 {
+				t.Fatalf("insert line numbers %d/%d, want 0/%d", l.OldLine, l.NewLine, newLine)
+			}
+			newText = append(newText, l.Text)
+		default:
+			t.Fatalf("unknown op %q", l.Op)
+		}
+	}
+	if strings.Join(oldText, "\n") != strings.Join(a, "\n") || len(oldText) != len(a) {
+		t.Fatalf("old side %q does not restore %q", oldText, a)
+	}
+	if strings.Join(newText, "\n") != strings.Join(b, "\n") || len(newText) != len(b) {
+		t.Fatalf("new side %q does not restore %q", newText, b)
+	}
+
+	stats := Summarize(lines)
+	if want := len(a) + len(b) - 2*lcsLength(a, b); stats.Added+stats.Removed != want {
+		t.Fatalf("diff of %q and %q has %d edits, shortest is %d", a, b, stats.Added+stats.Removed, want)
+	}
+}
+
+// lcsLength 动态规划求最长公共子序列长度，作为最短编辑距离的参照
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func randomLines(r *rand.Rand, alphabet int) []string {
+	lines := make([]string, r.Intn(20))
+	for i := range lines {
+		lines[i] = string(rune('a' + r.Intn(alphabet)))
+	}
+	return lines
+}
@@ -0,0 +1,372 @@
+/*
+开发心理过程：
+1. 同时按用户名和IP计数：只按用户名，攻击者换用户名撞库；只按IP，分布式攻击无法防住
+2. 计数存在数据库里，多个副本共享，换个实例重试也没用
+3. 每次失败后等待时间翻倍，达到上限后锁定一段时间；IP可能是多人共用的出口，上限比用户名宽松
+   验证前先把尝试记为进行中并按失败计算等待时间，同时发出的大量请求不能绕过退避
+4. 用户名不管是否存在都同样计数，响应不会暴露账号是否存在
+5. 每次锁定都记一条记录，管理员可以查看并手动解除
+6. 两步登录的挑战令牌也记在数据库里：验证通过后作废，错误次数达到上限后作废，换个副本重放也没用
+*/
+
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/netip"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+)
+
+// maxBackoff 单次退避等待的上限，锁定前不会等待更久
+const maxBackoff = time.Minute
+
+// pendingTimeout 进行中的尝试超过这么久没有结果，视为请求已中断
+const pendingTimeout = time.Minute
+
+// maxChallengeFailures 每个挑战令牌允许提交错误验证码的次数
+const maxChallengeFailures = 5
+
+// ErrChallengeInvalid 挑战令牌已经使用过，或错误次数已达上限
+var ErrChallengeInvalid = errors.New("login challenge already used or exhausted")
+
+// Options 限制参数
+type Options struct {
+	MaxFailures      int           // 同一用户名连续失败多少次后锁定
+	MaxFailuresPerIP int           // 同一IP连续失败多少次后锁定
+	LockoutDuration  time.Duration // 锁定时长
+	BackoffBase      time.Duration // 第一次失败后的等待时间，之后每次翻倍
+	FailureWindow    time.Duration // 超过这么久没有失败，计数重新开始
+}
+
+// Guard 登录失败限制
+type Guard struct {
+	db   *gorm.DB
+	opts Options
+}
+
+// Wait 需要等待的时间；Locked为true表示已被锁定，而不只是退避
+type Wait struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// New 创建登录失败限制
+func New(db *gorm.DB, opts Options) *Guard {
+	return &Guard{db: db, opts: opts}
+}
+
+// Attempt 在验证密码或验证码之前调用，用户名或IP需要等待时返回等待时间
+// 放行时把这次尝试记为进行中，计算等待时间时按失败对待，同时发出的请求不能绕过退避各尝试一次
+// 放行后调用方必须按验证结果调用Fail、Succeed或Release之一；都没有调用时超过pendingTimeout才不再计入
+func (g *Guard) Attempt(username, ip string) (Wait, error) {
+	var wait Wait
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var throttles []*models.LoginThrottle
+		for _, target := range targets(username, ip) {
+			t, err := lock(tx, target[0], target[1], now)
+			if err != nil {
+				return err
+			}
+			if w := g.wait(*t, now); w.RetryAfter > wait.RetryAfter {
+				wait = w
+			}
+			throttles = append(throttles, t)
+		}
+		if wait.RetryAfter > 0 {
+			return nil
+		}
+
+		for _, t := range throttles {
+			if err := tx.Model(t).Updates(map[string]interface{}{
+				"pending":      pending(*t, now) + 1,
+				"attempted_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Wait{}, err
+	}
+	return wait, nil
+}
+
+// Fail 记录一次失败，达到上限时锁定并写入锁定记录
+func (g *Guard) Fail(username, ip string) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := g.fail(tx, models.LoginScopeUser, UsernameKey(username), g.opts.MaxFailures, ip); err != nil {
+			return err
+		}
+		return g.fail(tx, models.LoginScopeIP, IPKey(ip), g.opts.MaxFailuresPerIP, ip)
+	})
+}
+
+// Succeed 登录成功后清除该用户名的失败计数；IP计数不清除，避免用自己的账号登录来重置，只结束进行中的这次尝试
+func (g *Guard) Succeed(username, ip string) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scope = ? AND key = ?", models.LoginScopeUser, UsernameKey(username)).
+			Delete(&models.LoginThrottle{}).Error; err != nil {
+			return err
+		}
+		return release(tx, models.LoginScopeIP, IPKey(ip))
+	})
+}
+
+// Release 结束Attempt放行的尝试但不计为失败，用于验证通过但登录还未完成，或请求无效没有进行验证的情况
+func (g *Guard) Release(username, ip string) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := release(tx, models.LoginScopeUser, UsernameKey(username)); err != nil {
+			return err
+		}
+		return release(tx, models.LoginScopeIP, IPKey(ip))
+	})
+}
+
+// Challenge 锁住挑战令牌后调用verify，同一令牌的并发提交依次处理
+// verify返回true时令牌作废，不能再换取访问令牌；返回false时错误次数加一；返回错误时不做任何记录
+func (g *Guard) Challenge(tokenID string, expiresAt time.Time, verify func(tx *gorm.DB) (bool, error)) (bool, error) {
+	var passed bool
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginChallenge{TokenID: tokenID, ExpiresAt: expiresAt}).Error; err != nil {
+			return err
+		}
+		var challenge models.LoginChallenge
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_id = ?", tokenID).
+			First(&challenge).Error; err != nil {
+			return err
+		}
+		if challenge.UsedAt != nil || challenge.Failures >= maxChallengeFailures {
+			return ErrChallengeInvalid
+		}
+
+		var err error
+		if passed, err = verify(tx); err != nil {
+			return err
+		}
+		if passed {
+			return tx.Model(&challenge).Update("used_at", time.Now()).Error
+		}
+		return tx.Model(&challenge).Update("failures", challenge.Failures+1).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return passed, nil
+}
+
+// Unlock 解除用户名或IP的锁定，返回解除的锁定记录数
+func (g *Guard) Unlock(username, ip string, adminID *uint) (int64, error) {
+	var unlocked int64
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		for _, target := range [][2]string{
+			{models.LoginScopeUser, UsernameKey(username)},
+			{models.LoginScopeIP, IPKey(ip)},
+		} {
+			if target[1] == "" {
+				continue
+			}
+			if err := tx.Where("scope = ? AND key = ?", target[0], target[1]).
+				Delete(&models.LoginThrottle{}).Error; err != nil {
+				return err
+			}
+			result := tx.Model(&models.LoginLockout{}).
+				Where("scope = ? AND key = ? AND unlocked_at IS NULL AND locked_until > ?", target[0], target[1], time.Now()).
+				Updates(map[string]interface{}{"unlocked_at": time.Now(), "unlocked_by_id": adminID})
+			if result.Error != nil {
+				return result.Error
+			}
+			unlocked += result.RowsAffected
+		}
+		return nil
+	})
+	return unlocked, err
+}
+
+// Run 定期删除早已失效的失败计数，直到ctx取消
+func (g *Guard) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := g.Purge(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Login throttle purge failed: %v", err)
+			}
+		}
+	}
+}
+
+// Purge 删除超过计数窗口且未处于锁定中的失败计数，以及已过期的挑战令牌记录
+func (g *Guard) Purge(ctx context.Context) error {
+	now := time.Now()
+	if err := g.db.WithContext(ctx).
+		Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-g.opts.FailureWindow), now).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		return err
+	}
+	return g.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.LoginChallenge{}).Error
+}
+
+// wait 计算某条失败计数要求的等待时间，进行中的尝试按失败计算
+func (g *Guard) wait(t models.LoginThrottle, now time.Time) Wait {
+	if t.LockedUntil != nil {
+		if now.Before(*t.LockedUntil) {
+			return Wait{RetryAfter: t.LockedUntil.Sub(now), Locked: true}
+		}
+		return Wait{}
+	}
+	failures, last := t.Failures, t.LastFailedAt
+	if now.Sub(last) > g.opts.FailureWindow {
+		failures = 0
+	}
+	if n := pending(t, now); n > 0 {
+		failures += n
+		if t.AttemptedAt.After(last) {
+			last = *t.AttemptedAt
+		}
+	}
+	if failures <= 0 {
+		return Wait{}
+	}
+	if retryAt := last.Add(g.backoff(failures)); now.Before(retryAt) {
+		return Wait{RetryAfter: retryAt.Sub(now)}
+	}
+	return Wait{}
+}
+
+// backoff 第n次失败后的等待时间
+func (g *Guard) backoff(failures int) time.Duration {
+	delay := g.opts.BackoffBase
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// lock 在事务中锁住一条失败计数；先插入空行再加锁读取，并发的首次尝试不会冲突
+func lock(tx *gorm.DB, scope, key string, now time.Time) (*models.LoginThrottle, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LoginThrottle{Scope: scope, Key: key, LastFailedAt: now}).Error; err != nil {
+		return nil, err
+	}
+	var t models.LoginThrottle
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND key = ?", scope, key).
+		First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// fail 在事务中给一条失败计数加一，并结束一次进行中的尝试
+func (g *Guard) fail(tx *gorm.DB, scope, key string, maxFailures int, ip string) error {
+	if key == "" {
+		return nil
+	}
+
+	now := time.Now()
+	t, err := lock(tx, scope, key, now)
+	if err != nil {
+		return err
+	}
+
+	// 锁定已过期或长时间没有失败，重新计数
+	if (t.LockedUntil != nil && now.After(*t.LockedUntil)) || now.Sub(t.LastFailedAt) > g.opts.FailureWindow {
+		t.Failures = 0
+		t.LockedUntil = nil
+	}
+	t.Failures++
+	t.LastFailedAt = now
+
+	if maxFailures > 0 && t.Failures >= maxFailures && t.LockedUntil == nil {
+		lockedUntil := now.Add(g.opts.LockoutDuration)
+		t.LockedUntil = &lockedUntil
+		if err := tx.Create(&models.LoginLockout{
+			Scope:       scope,
+			Key:         key,
+			Failures:    t.Failures,
+			IP:          ip,
+			LockedUntil: lockedUntil,
+		}).Error; err != nil {
+			return err
+		}
+		log.Printf("🔒 Login locked for %s %q until %s after %d failures", scope, key, lockedUntil.Format(time.RFC3339), t.Failures)
+	}
+
+	return tx.Model(t).Updates(map[string]interface{}{
+		"failures":       t.Failures,
+		"last_failed_at": t.LastFailedAt,
+		"locked_until":   t.LockedUntil,
+		"pending":        max(pending(*t, now)-1, 0),
+	}).Error
+}
+
+// release 结束一次进行中的尝试
+func release(tx *gorm.DB, scope, key string) error {
+	if key == "" {
+		return nil
+	}
+	return tx.Model(&models.LoginThrottle{}).
+		Where("scope = ? AND key = ? AND pending > 0", scope, key).
+		Update("pending", gorm.Expr("pending - 1")).Error
+}
+
+// pending 仍在进行中的尝试数；超过pendingTimeout的视为请求已中断，不再计入
+func pending(t models.LoginThrottle, now time.Time) int {
+	if t.Pending <= 0 || t.AttemptedAt == nil || now.Sub(*t.AttemptedAt) > pendingTimeout {
+		return 0
+	}
+	return t.Pending
+}
+
+// targets 需要计数的用户名和IP，总是先用户名后IP加锁，避免并发事务互相等待；IP无法解析时不按IP计数
+func targets(username, ip string) [][2]string {
+	var list [][2]string
+	if key := UsernameKey(username); key != "" {
+		list = append(list, [2]string{models.LoginScopeUser, key})
+	}
+	if key := IPKey(ip); key != "" {
+		list = append(list, [2]string{models.LoginScopeIP, key})
+	}
+	return list
+}
+
+// UsernameKey 用户名计数键，不区分大小写
+func UsernameKey(username string) string {
+	key := []rune(strings.ToLower(strings.TrimSpace(username)))
+	if len(key) > 100 {
+		key = key[:100]
+	}
+	return string(key)
+}
+
+// IPKey IP计数键；IPv6用户通常能支配整个/64，按/64计数
+func IPKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(64)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
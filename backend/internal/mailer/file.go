@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File 把邮件保存为目录下的.eml文件，用于开发和测试，可以用邮件客户端直接打开
+type File struct {
+	dir  string
+	from *mail.Address
+}
+
+// NewFile 创建保存到目录的发送方式，目录不存在时自动创建
+func NewFile(dir string, from *mail.Address) (*File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send 把邮件写入新文件，文件名以时间开头便于按顺序查看
+func (f *File) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := compose(f.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(f.dir, now.Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("📧 Mail to %s saved to %s", msg.To, filepath.Base(file.Name()))
+	return nil
+}
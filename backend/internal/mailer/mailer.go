@@ -0,0 +1,130 @@
+/*
+开发心理过程：
+1. 业务代码只依赖Mailer接口，发送方式由配置决定，找回密码等功能不关心邮件怎么发出去
+2. 生产环境通过SMTP发送，主流邮件服务商和自建邮件服务器都支持
+3. 开发和测试时没有邮件服务器，邮件写入日志或保存为.eml文件，能直接看到重置链接
+4. 邮件只发纯文本，不需要模板引擎，所有邮件客户端都能正常显示
+*/
+
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"techblog-api/backend/internal/config"
+)
+
+// 支持的发送方式
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+// Message 待发送的邮件
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer 邮件发送方式
+type Mailer interface {
+	// Send 发送一封邮件，返回前邮件已交给邮件服务器或写入完成
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 按配置创建发送方式
+func New(cfg *config.Config, driver string) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.MailFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAIL_FROM %q: %w", cfg.MailFrom, err)
+	}
+
+	switch driver {
+	case DriverSMTP:
+		return NewSMTP(SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+			From:     from,
+			Timeout:  30 * time.Second,
+		})
+	case DriverFile:
+		return NewFile(cfg.MailDir, from)
+	case DriverLog:
+		return NewLog(from), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", driver)
+	}
+}
+
+// Log 把邮件写入日志，只用于开发环境
+type Log struct {
+	from *mail.Address
+}
+
+// NewLog 创建写入日志的发送方式
+func NewLog(from *mail.Address) *Log {
+	return &Log{from: from}
+}
+
+// Send 把邮件内容写入日志
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	log.Printf("📧 Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// compose 生成RFC 5322格式的邮件，正文使用UTF-8和quoted-printable编码
+func compose(from *mail.Address, msg Message, now time.Time) ([]byte, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + to.String() + "\r\n")
+	b.WriteString("Subject: " + encodeHeader(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	fmt.Fprintf(&b, "Message-ID: <%d@%s>\r\n", now.UnixNano(), domain(from.Address))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(msg.Text)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// encodeHeader 编码含非ASCII字符的邮件头，并去掉换行防止注入其他邮件头
+func encodeHeader(value string) string {
+	value = strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+// domain 邮件地址的域名部分
+func domain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP连接的加密方式
+const (
+	TLSStartTLS = "starttls" // 明文连接后升级，通常为587端口
+	TLSImplicit = "tls"      // 直接建立TLS连接，通常为465端口
+	TLSNone     = "none"     // 不加密，只用于本机或内网的邮件服务
+)
+
+// SMTPOptions SMTP连接参数
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string // 为空时不认证
+	Password string
+	TLS      string
+	From     *mail.Address
+	Timeout  time.Duration // 单封邮件从连接到发送完成的时限
+}
+
+// SMTP 通过SMTP服务器发送邮件，每封邮件单独建立连接
+type SMTP struct {
+	opts SMTPOptions
+}
+
+// NewSMTP 创建SMTP发送方式
+func NewSMTP(opts SMTPOptions) (*SMTP, error) {
+	if opts.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	switch opts.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP TLS mode %q", opts.TLS)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	return &SMTP{opts: opts}, nil
+}
+
+// Send 连接SMTP服务器发送邮件
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	data, err := compose(s.opts.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	// net/smtp不支持context，用连接的截止时间限制整个会话
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.opts.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.opts.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.opts.Host}); err != nil {
+			return err
+		}
+	}
+	if s.opts.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.opts.From.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial 按加密方式建立连接
+func (s *SMTP) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port))
+	if s.opts.TLS == TLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.opts.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
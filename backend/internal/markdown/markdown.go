@@ -0,0 +1,237 @@
+/*
+开发心理过程：
+1. 正文以Markdown保存，保存时在服务端渲染为HTML并缓存，客户端不再各自渲染
+2. 支持GFM（表格、任务列表、删除线、自动链接）和脚注
+3. 代码块使用chroma高亮并输出内联样式，前端无需额外引入样式表
+4. 标题id只由标题文本生成，保留中日韩等各语言文字，重复时追加-1、-2；
+   其他段落的增删不会改变已有锚点，需要固定锚点时可写 "## 标题 {#custom-id}"
+5. 同一次解析中提取H2–H4生成嵌套目录，保证目录与正文锚点一致
+6. 渲染结果统一经过白名单过滤，作者在Markdown中写入的HTML也不会带入脚本
+*/
+
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"golang.org/x/text/unicode/norm"
+)
+
+// HighlightStyle 代码高亮使用的chroma主题
+const HighlightStyle = "github"
+
+// AnchorClass 标题锚点链接的class
+const AnchorClass = "heading-anchor"
+
+var (
+	engine = goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(HighlightStyle),
+				highlighting.WithGuessLanguage(false),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithAttribute(),
+		),
+	)
+	policy = newPolicy()
+)
+
+// 目录包含的标题层级
+const (
+	TOCMinLevel = 2
+	TOCMaxLevel = 4
+)
+
+// Heading 目录条目
+type Heading struct {
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Level    int       `json:"level"`
+	Children []Heading `json:"children,omitempty"`
+}
+
+// Document 渲染结果
+type Document struct {
+	HTML string
+	TOC  []Heading
+}
+
+// Render 将Markdown渲染为经过白名单过滤的HTML，并提取目录
+func Render(source string) (*Document, error) {
+	src := []byte(source)
+	pc := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := engine.Parser().Parse(text.NewReader(src), parser.WithContext(pc))
+
+	var flat []Heading
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, ok := heading.AttributeString("id")
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		idBytes, ok := id.([]byte)
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+
+		if heading.Level >= TOCMinLevel && heading.Level <= TOCMaxLevel {
+			flat = append(flat, Heading{ID: string(idBytes), Text: plainText(heading, src), Level: heading.Level})
+		}
+
+		// 标题末尾追加指向自身的锚点链接
+		link := ast.NewLink()
+		link.Destination = append([]byte("#"), idBytes...)
+		link.SetAttributeString("class", []byte(AnchorClass))
+		link.AppendChild(link, ast.NewString([]byte("#")))
+		heading.AppendChild(heading, link)
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := engine.Renderer().Render(&buf, src, doc); err != nil {
+		return nil, err
+	}
+	toc := nest(flat)
+	if toc == nil {
+		toc = []Heading{}
+	}
+	return &Document{HTML: policy.Sanitize(buf.String()), TOC: toc}, nil
+}
+
+// nest 按层级把标题组织为树，跳级的标题挂到最近的上级下
+func nest(flat []Heading) []Heading {
+	var build func(i, parentLevel int) ([]Heading, int)
+	build = func(i, parentLevel int) ([]Heading, int) {
+		var nodes []Heading
+		for i < len(flat) && flat[i].Level > parentLevel {
+			node := flat[i]
+			node.Children, i = build(i+1, node.Level)
+			nodes = append(nodes, node)
+		}
+		return nodes, i
+	}
+	nodes, _ := build(0, 0)
+	return nodes
+}
+
+// plainText 提取标题的纯文本
+func plainText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// headingIDs 生成标题id：保留各语言的字母和数字，其余字符折叠为连字符
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]bool)}
+}
+
+// Generate 实现 parser.IDs
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := anchorID(string(value))
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for n := 1; h.used[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	h.used[id] = true
+	return []byte(id)
+}
+
+// Put 实现 parser.IDs，记录作者手写的id
+func (h *headingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
+
+// anchorID 将标题文本转换为锚点id
+func anchorID(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(norm.NFC.String(title)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		case r == '_' || r == '-' || unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+var (
+	safeClass   = regexp.MustCompile(`^[\w\- ]+$`)
+	safeID      = regexp.MustCompile(`^[\p{L}\p{N}\p{Mn}_:.\-]+$`)
+	safeRole    = regexp.MustCompile(`^doc-[a-z]+$`)
+	safeInputTy = regexp.MustCompile(`^checkbox$`)
+)
+
+// newPolicy 在UGC策略基础上放行高亮样式、标题锚点、任务列表和脚注所需的属性
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// 站内锚点与脚注链接不加nofollow
+	p.RequireNoFollowOnLinks(false)
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+
+	p.AllowAttrs("class").Matching(safeClass).Globally()
+	p.AllowAttrs("id").Matching(safeID).OnElements("h1", "h2", "h3", "h4", "h5", "h6", "li", "sup", "div")
+	p.AllowAttrs("role").Matching(safeRole).OnElements("a", "div", "section")
+
+	// chroma内联样式只保留配色相关属性
+	p.AllowStyles("color", "background-color", "font-weight", "font-style", "text-decoration").Globally()
+	p.AllowStyles("white-space", "tab-size", "-moz-tab-size").OnElements("pre")
+
+	// GFM任务列表
+	p.AllowElements("input")
+	p.AllowAttrs("type").Matching(safeInputTy).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+
+	return p
+}
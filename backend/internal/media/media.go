@@ -0,0 +1,114 @@
+/*
+开发心理过程：
+1. 扩展名和实际内容都要检查：先按文件头嗅探MIME类型，再要求扩展名与之匹配
+2. 只接受常见位图格式，SVG可以内嵌脚本，不允许上传
+3. 能解码出尺寸才算合法图片，顺便记录宽高供前端排版
+4. 文件名使用内容的SHA-256，同一张图片重复上传只保存一份，也不暴露原文件名
+5. 文件的读写交给storage包，本地磁盘和对象存储使用相同的相对路径
+*/
+
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrTooLarge 文件超过大小限制
+	ErrTooLarge = errors.New("file too large")
+	// ErrUnsupportedType 文件内容不是允许的图片格式
+	ErrUnsupportedType = errors.New("unsupported file type")
+	// ErrExtensionMismatch 扩展名与文件内容不一致
+	ErrExtensionMismatch = errors.New("file extension does not match its content")
+)
+
+// allowed 允许上传的MIME类型及扩展名，第一个扩展名用于保存
+var allowed = map[string][]string{
+	"image/jpeg": {".jpg", ".jpeg"},
+	"image/png":  {".png"},
+	"image/gif":  {".gif"},
+	"image/webp": {".webp"},
+}
+
+// File 通过检查的上传文件
+type File struct {
+	Data     []byte
+	Hash     string
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+}
+
+// Size 文件字节数
+func (f *File) Size() int64 {
+	return int64(len(f.Data))
+}
+
+// Path 文件在存储中的对象键，按哈希前两位分目录
+func (f *File) Path() string {
+	return path.Join(f.Hash[:2], f.Hash+f.Ext)
+}
+
+// ReadLimited 读取全部内容，超过maxSize字节时返回ErrTooLarge
+func ReadLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Inspect 检查文件内容和扩展名，返回哈希、类型和尺寸
+func Inspect(name string, data []byte) (*File, error) {
+	mimeType := http.DetectContentType(data)
+	exts, ok := allowed[mimeType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	matched := false
+	for _, allowedExt := range exts {
+		if ext == allowedExt {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil, ErrExtensionMismatch
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupportedType
+	}
+
+	sum := sha256.Sum256(data)
+	return &File{
+		Data:     data,
+		Hash:     hex.EncodeToString(sum[:]),
+		MimeType: mimeType,
+		Ext:      exts[0],
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+	}, nil
+}
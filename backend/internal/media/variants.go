@@ -0,0 +1,277 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"techblog-api/backend/internal/storage"
+)
+
+// variantDir 缩略图缓存目录，与原图位于同一个存储中
+const variantDir = ".variants"
+
+// Variants 按需生成并缓存缩略图
+// 每个宽度生成WebP和原格式两个版本，响应时在客户端支持的版本中选体积更小的
+type Variants struct {
+	store     storage.Storage
+	widths    []int
+	maxPixels int
+	// 生成缩略图需要解码整张原图，限制并发以控制内存占用
+	sem chan struct{}
+
+	// 已确认的缩略图信息，对象存储上每次查询都是一次网络请求
+	mu    sync.Mutex
+	known map[string]variantInfo
+}
+
+// variantInfo 某个宽度的缩略图情况
+type variantInfo struct {
+	skip         bool // 不生成缩略图，使用原图
+	webpSize     int64
+	fallbackSize int64
+}
+
+// Variant 可直接返回给客户端的文件
+type Variant struct {
+	Key      string // 对象键
+	Original bool   // 没有合适的缩略图，返回的是原图
+}
+
+// NewVariants 创建缩略图生成器，widths为允许的宽度，maxPixels为可处理的最大像素数
+func NewVariants(store storage.Storage, widths []int, maxPixels int) *Variants {
+	sorted := append([]int(nil), widths...)
+	sort.Ints(sorted)
+	return &Variants{
+		store:     store,
+		widths:    sorted,
+		maxPixels: maxPixels,
+		sem:       make(chan struct{}, 1),
+		known:     make(map[string]variantInfo),
+	}
+}
+
+// Widths 允许的缩略图宽度，从小到大
+func (v *Variants) Widths() []int {
+	return v.widths
+}
+
+// Snap 把请求的宽度对齐到不小于它的允许宽度，超出时取最大值，避免任意宽度占满磁盘
+func (v *Variants) Snap(width int) int {
+	for _, w := range v.widths {
+		if w >= width {
+			return w
+		}
+	}
+	if len(v.widths) == 0 {
+		return 0
+	}
+	return v.widths[len(v.widths)-1]
+}
+
+// Supports 该类型的图片是否生成缩略图，GIF可能是动图，始终返回原图
+func Supports(mimeType string) bool {
+	return mimeType != "image/gif"
+}
+
+// Get 返回rel对应原图在指定宽度下的版本，缩略图不存在时生成
+// 原图不比目标宽度大、过大无法处理或格式不支持时返回原图
+func (v *Variants) Get(ctx context.Context, rel string, width int, acceptWebP bool) (*Variant, error) {
+	rel = strings.TrimPrefix(path.Clean("/"+rel), "/")
+	webpKey, fallbackKey := variantKeys(rel, width)
+	if fallbackKey == "" || width <= 0 {
+		if _, err := v.store.Stat(ctx, rel); err != nil {
+			return nil, err
+		}
+		return &Variant{Key: rel, Original: true}, nil
+	}
+
+	info, ok := v.lookup(fallbackKey)
+	if !ok {
+		var err error
+		if info, err = v.load(ctx, rel, width, webpKey, fallbackKey); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case info.skip:
+		return &Variant{Key: rel, Original: true}, nil
+	case acceptWebP && info.webpSize > 0 && info.webpSize <= info.fallbackSize:
+		return &Variant{Key: webpKey}, nil
+	default:
+		return &Variant{Key: fallbackKey}, nil
+	}
+}
+
+// errSkipVariant 不需要或无法生成缩略图，直接使用原图
+var errSkipVariant = errors.New("variant not applicable")
+
+// variantKeys 缩略图的对象键；WebP原图的兼容版本使用PNG，其余沿用原格式
+func variantKeys(rel string, width int) (webpKey, fallbackKey string) {
+	ext := strings.ToLower(path.Ext(rel))
+	var fallbackExt string
+	switch ext {
+	case ".jpg", ".jpeg":
+		fallbackExt = ".jpg"
+	case ".png", ".webp":
+		fallbackExt = ".png"
+	default:
+		return "", ""
+	}
+
+	base := variantPrefix(rel) + strconv.Itoa(width)
+	return base + ".webp", base + fallbackExt
+}
+
+// variantPrefix 原图全部缩略图共同的键前缀
+func variantPrefix(rel string) string {
+	return path.Join(variantDir, strings.TrimSuffix(rel, path.Ext(rel))) + "-w"
+}
+
+func (v *Variants) lookup(key string) (variantInfo, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	info, ok := v.known[key]
+	return info, ok
+}
+
+func (v *Variants) remember(key string, info variantInfo) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.known[key] = info
+}
+
+// load 查询存储中已有的缩略图，没有时生成；并发请求在信号量上排队，拿到后先检查是否已生成
+func (v *Variants) load(ctx context.Context, rel string, width int, webpKey, fallbackKey string) (variantInfo, error) {
+	info, err := v.stat(ctx, webpKey, fallbackKey)
+	if err == nil {
+		v.remember(fallbackKey, info)
+		return info, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return variantInfo{}, err
+	}
+
+	v.sem <- struct{}{}
+	defer func() { <-v.sem }()
+	if info, ok := v.lookup(fallbackKey); ok {
+		return info, nil
+	}
+
+	info, err = v.generate(ctx, rel, width, webpKey, fallbackKey)
+	if errors.Is(err, errSkipVariant) {
+		info, err = variantInfo{skip: true}, nil
+	}
+	if err != nil {
+		return variantInfo{}, err
+	}
+	v.remember(fallbackKey, info)
+	return info, nil
+}
+
+// stat 兼容版本最后写入，它存在即表示两个版本都已生成
+func (v *Variants) stat(ctx context.Context, webpKey, fallbackKey string) (variantInfo, error) {
+	fallback, err := v.store.Stat(ctx, fallbackKey)
+	if err != nil {
+		return variantInfo{}, err
+	}
+	info := variantInfo{fallbackSize: fallback.Size}
+	if webp, err := v.store.Stat(ctx, webpKey); err == nil {
+		info.webpSize = webp.Size
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return variantInfo{}, err
+	}
+	return info, nil
+}
+
+// generate 解码原图并生成两个版本
+func (v *Variants) generate(ctx context.Context, rel string, width int, webpKey, fallbackKey string) (variantInfo, error) {
+	r, err := v.store.Open(ctx, rel)
+	if err != nil {
+		return variantInfo{}, err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return variantInfo{}, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return variantInfo{}, errSkipVariant
+	}
+	if cfg.Width <= width || cfg.Width*cfg.Height > v.maxPixels || format == "gif" {
+		return variantInfo{}, errSkipVariant
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return variantInfo{}, errSkipVariant
+	}
+
+	height := max(1, int(float64(cfg.Height)*float64(width)/float64(cfg.Width)+0.5))
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var webpBuf, fallbackBuf bytes.Buffer
+	if err := EncodeWebP(&webpBuf, dst); err != nil {
+		return variantInfo{}, err
+	}
+	fallbackType := "image/png"
+	if strings.HasSuffix(fallbackKey, ".jpg") {
+		fallbackType = "image/jpeg"
+		err = jpeg.Encode(&fallbackBuf, dst, &jpeg.Options{Quality: 82})
+	} else {
+		err = png.Encode(&fallbackBuf, dst)
+	}
+	if err != nil {
+		return variantInfo{}, err
+	}
+
+	info := variantInfo{webpSize: int64(webpBuf.Len()), fallbackSize: int64(fallbackBuf.Len())}
+	if err := v.store.Put(ctx, webpKey, &webpBuf, info.webpSize, "image/webp"); err != nil {
+		return variantInfo{}, err
+	}
+	if err := v.store.Put(ctx, fallbackKey, &fallbackBuf, info.fallbackSize, fallbackType); err != nil {
+		return variantInfo{}, err
+	}
+	return info, nil
+}
+
+// RemoveVariants 删除原图对应的全部缩略图
+func (v *Variants) RemoveVariants(ctx context.Context, rel string) error {
+	rel = strings.TrimPrefix(path.Clean("/"+rel), "/")
+	prefix := variantPrefix(rel)
+
+	v.mu.Lock()
+	for key := range v.known {
+		if strings.HasPrefix(key, prefix) {
+			delete(v.known, key)
+		}
+	}
+	v.mu.Unlock()
+
+	var keys []string
+	if err := v.store.Walk(ctx, prefix, func(obj storage.Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := v.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
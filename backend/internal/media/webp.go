@@ -0,0 +1,634 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+const (
+	webpMaxDimension = 1 << 14
+	predictorBits    = 4
+	maxBackRefLength = 4096
+	minBackRefLength = 3
+	numLengthCodes   = 24
+	numDistanceCodes = 40
+	maxCodeLength    = 15
+	maxCLCodeLength  = 7
+
+	// 二维距离表中(0,1)和(1,0)对应的距离码，即上方和左侧像素
+	distanceCodeTop  = 1
+	distanceCodeLeft = 2
+)
+
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP 将图片编码为无损WebP（VP8L），纯Go实现，不依赖CGO
+//   - 变换：减绿，加上按16x16块选择预测模式的预测变换
+//   - 熵编码：每个通道一棵长度受限的Huffman树，不使用颜色缓存和分组
+//   - 回溯引用只尝试左侧和上方像素，足以压缩截图中的大片纯色和重复行
+//
+// 照片无损编码通常比JPEG大，调用方应比较体积后再决定使用哪个版本
+func EncodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 || width > webpMaxDimension || height > webpMaxDimension {
+		return errors.New("webp: invalid image size")
+	}
+
+	argb, hasAlpha := toARGB(img)
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(boolBit(hasAlpha), 1)
+	bw.write(0, 3)
+
+	// 减绿变换
+	bw.write(1, 1)
+	bw.write(2, 2)
+	subtractGreen(argb)
+
+	// 预测变换
+	bw.write(1, 1)
+	bw.write(0, 2)
+	bw.write(predictorBits-2, 3)
+	modes, residuals := applyPredictor(argb, width, height)
+	encodeImageData(bw, modes, subSampleSize(width), false)
+
+	bw.write(0, 1)
+	encodeImageData(bw, residuals, width, true)
+	data := bw.bytes()
+
+	chunk := len(data)
+	pad := chunk & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+chunk+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(chunk))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if pad == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// toARGB 转换为非预乘的ARGB像素
+func toARGB(img image.Image) ([]uint32, bool) {
+	b := img.Bounds()
+	argb := make([]uint32, 0, b.Dx()*b.Dy())
+	hasAlpha := false
+	nrgba, isNRGBA := img.(*image.NRGBA)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var c color.NRGBA
+			if isNRGBA {
+				i := nrgba.PixOffset(x, y)
+				c = color.NRGBA{nrgba.Pix[i], nrgba.Pix[i+1], nrgba.Pix[i+2], nrgba.Pix[i+3]}
+			} else {
+				c = color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			}
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			argb = append(argb, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+	return argb, hasAlpha
+}
+
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		b := (p - g) & 0xff
+		argb[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+func subSampleSize(size int) int {
+	return (size + 1<<predictorBits - 1) >> predictorBits
+}
+
+// applyPredictor 为每个块选择残差最小的预测模式，返回模式子图和残差
+func applyPredictor(argb []uint32, width, height int) ([]uint32, []uint32) {
+	bw, bh := subSampleSize(width), subSampleSize(height)
+	modes := make([]uint32, bw*bh)
+	residuals := make([]uint32, len(argb))
+
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			x0, y0 := bx<<predictorBits, by<<predictorBits
+			x1, y1 := min(x0+1<<predictorBits, width), min(y0+1<<predictorBits, height)
+
+			bestMode, bestCost := 0, -1
+			for mode := 0; mode < 14; mode++ {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						i := y*width + x
+						cost += residualCost(subPixels(argb[i], predictPixel(argb, x, y, width, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			modes[by*bw+bx] = 0xff000000 | uint32(bestMode)<<8
+
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					i := y*width + x
+					residuals[i] = subPixels(argb[i], predictPixel(argb, x, y, width, bestMode))
+				}
+			}
+		}
+	}
+	return modes, residuals
+}
+
+// predictPixel 按VP8L规范计算预测值，首行用左侧像素、首列用上方像素
+func predictPixel(argb []uint32, x, y, width, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[i-1]
+	case x == 0:
+		return argb[i-width]
+	}
+
+	// 最右一列的右上像素是当前行最左侧的像素，按下标计算正好如此
+	l, t, tl, tr := argb[i-1], argb[i-width], argb[i-width-1], argb[i-width+1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return average2(average2(l, tr), t)
+	case 6:
+		return average2(l, tl)
+	case 7:
+		return average2(l, t)
+	case 8:
+		return average2(tl, t)
+	case 9:
+		return average2(t, tr)
+	case 10:
+		return average2(average2(l, tl), average2(t, tr))
+	case 11:
+		return selectPixel(l, t, tl)
+	case 12:
+		return clampAddSubtractFull(l, t, tl)
+	default:
+		return clampAddSubtractHalf(average2(l, t), tl)
+	}
+}
+
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+func selectPixel(l, t, tl uint32) uint32 {
+	pl, pt := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		lc, tc, tlc := int(l>>shift&0xff), int(t>>shift&0xff), int(tl>>shift&0xff)
+		pl += abs(tc - tlc)
+		pt += abs(lc - tlc)
+	}
+	if pl < pt {
+		return l
+	}
+	return t
+}
+
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(a>>shift&0xff) + int(b>>shift&0xff) - int(c>>shift&0xff)
+		out |= uint32(clamp255(v)) << shift
+	}
+	return out
+}
+
+func clampAddSubtractHalf(a, b uint32) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		ac, bc := int(a>>shift&0xff), int(b>>shift&0xff)
+		out |= uint32(clamp255(ac+(ac-bc)/2)) << shift
+	}
+	return out
+}
+
+func subPixels(a, b uint32) uint32 {
+	alphaAndGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redAndBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return alphaAndGreen&0xff00ff00 | redAndBlue&0x00ff00ff
+}
+
+// residualCost 以各通道残差绝对值之和估计编码代价
+func residualCost(p uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(int8(p >> shift))
+		cost += abs(v)
+	}
+	return cost
+}
+
+// token 一个字面像素或一次回溯引用
+type token struct {
+	pixel    uint32
+	length   int
+	distance int
+}
+
+// encodeImageData 写入熵编码的图像数据，主图比子图多一个分组标志位
+func encodeImageData(bw *bitWriter, argb []uint32, width int, main bool) {
+	tokens := backwardReferences(argb, width)
+
+	green := make([]uint32, 256+numLengthCodes)
+	red := make([]uint32, 256)
+	blue := make([]uint32, 256)
+	alpha := make([]uint32, 256)
+	dist := make([]uint32, numDistanceCodes)
+	for _, t := range tokens {
+		if t.length == 0 {
+			green[t.pixel>>8&0xff]++
+			red[t.pixel>>16&0xff]++
+			blue[t.pixel&0xff]++
+			alpha[t.pixel>>24]++
+			continue
+		}
+		code, _, _ := prefixEncode(t.length)
+		green[256+code]++
+		code, _, _ = prefixEncode(t.distance)
+		dist[code]++
+	}
+
+	bw.write(0, 1) // 不使用颜色缓存
+	if main {
+		bw.write(0, 1) // 整张图使用同一组Huffman树
+	}
+	codes := [5]*huffmanCode{
+		writeHuffmanCode(bw, green),
+		writeHuffmanCode(bw, red),
+		writeHuffmanCode(bw, blue),
+		writeHuffmanCode(bw, alpha),
+		writeHuffmanCode(bw, dist),
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int(t.pixel>>8&0xff))
+			codes[1].write(bw, int(t.pixel>>16&0xff))
+			codes[2].write(bw, int(t.pixel&0xff))
+			codes[3].write(bw, int(t.pixel>>24))
+			continue
+		}
+		code, bits, extra := prefixEncode(t.length)
+		codes[0].write(bw, 256+code)
+		bw.write(extra, bits)
+		code, bits, extra = prefixEncode(t.distance)
+		codes[4].write(bw, code)
+		bw.write(extra, bits)
+	}
+}
+
+// backwardReferences 贪心查找与左侧或上方像素重复的连续片段
+func backwardReferences(argb []uint32, width int) []token {
+	tokens := make([]token, 0, len(argb)/2)
+	for i := 0; i < len(argb); {
+		best, code := 0, 0
+		if i >= 1 {
+			if n := matchLength(argb, i, 1); n > best {
+				best, code = n, distanceCodeLeft
+			}
+		}
+		if i >= width {
+			if n := matchLength(argb, i, width); n > best {
+				best, code = n, distanceCodeTop
+			}
+		}
+		if best >= minBackRefLength {
+			tokens = append(tokens, token{length: best, distance: code})
+			i += best
+			continue
+		}
+		tokens = append(tokens, token{pixel: argb[i]})
+		i++
+	}
+	return tokens
+}
+
+func matchLength(argb []uint32, i, distance int) int {
+	n := 0
+	for i+n < len(argb) && n < maxBackRefLength && argb[i+n] == argb[i+n-distance] {
+		n++
+	}
+	return n
+}
+
+// prefixEncode 将长度或距离编码为前缀码和额外位
+func prefixEncode(value int) (code int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	h := 31
+	for d>>h == 0 {
+		h--
+	}
+	second := (d >> (h - 1)) & 1
+	extraBits = uint(h - 1)
+	return 2*h + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// huffmanCode 规范Huffman编码，bits为实际写出的位数（只有一个符号时为0）
+type huffmanCode struct {
+	codes []uint32
+	bits  []uint8
+}
+
+func (h *huffmanCode) write(bw *bitWriter, symbol int) {
+	bw.write(h.codes[symbol], uint(h.bits[symbol]))
+}
+
+// writeHuffmanCode 写入Huffman树描述并返回编码表；最多两个小于256的符号时使用简单编码
+func writeHuffmanCode(bw *bitWriter, freq []uint32) *huffmanCode {
+	var used []int
+	for symbol, f := range freq {
+		if f > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.write(1, 1)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+		}
+
+		lengths := make([]uint8, len(freq))
+		for _, symbol := range used {
+			lengths[symbol] = 1
+		}
+		return canonicalCode(lengths)
+	}
+
+	lengths := huffmanLengths(freq, maxCodeLength)
+
+	// 码长序列：连续的0用17/18压缩，其余原样写出
+	type clToken struct{ symbol, extra int }
+	var clTokens []clToken
+	clFreq := make([]uint32, 19)
+	for i := 0; i < len(lengths); {
+		if lengths[i] == 0 {
+			run := 1
+			for i+run < len(lengths) && lengths[i+run] == 0 && run < 138 {
+				run++
+			}
+			switch {
+			case run >= 11:
+				clTokens = append(clTokens, clToken{18, run - 11})
+			case run >= 3:
+				clTokens = append(clTokens, clToken{17, run - 3})
+			default:
+				run = 1
+				clTokens = append(clTokens, clToken{0, 0})
+			}
+			clFreq[clTokens[len(clTokens)-1].symbol]++
+			i += run
+			continue
+		}
+		clTokens = append(clTokens, clToken{int(lengths[i]), 0})
+		clFreq[lengths[i]]++
+		i++
+	}
+
+	clLengths := huffmanLengths(clFreq, maxCLCodeLength)
+	n := len(codeLengthOrder)
+	for n > 4 && clLengths[codeLengthOrder[n-1]] == 0 {
+		n--
+	}
+	bw.write(0, 1)
+	bw.write(uint32(n-4), 4)
+	for _, symbol := range codeLengthOrder[:n] {
+		bw.write(uint32(clLengths[symbol]), 3)
+	}
+	bw.write(0, 1) // 码长数量等于字母表大小
+
+	clCode := canonicalCode(clLengths)
+	for _, t := range clTokens {
+		clCode.write(bw, t.symbol)
+		switch t.symbol {
+		case 17:
+			bw.write(uint32(t.extra), 3)
+		case 18:
+			bw.write(uint32(t.extra), 7)
+		}
+	}
+	return canonicalCode(lengths)
+}
+
+// huffmanLengths 计算码长，超过limit时把频率减半后重建，直到满足限制
+func huffmanLengths(freq []uint32, limit int) []uint8 {
+	f := append([]uint32(nil), freq...)
+	for {
+		lengths, maxLength := buildHuffman(f)
+		if maxLength <= limit {
+			return lengths
+		}
+		for i := range f {
+			if f[i] > 0 {
+				f[i] = f[i]>>1 | 1
+			}
+		}
+	}
+}
+
+func buildHuffman(freq []uint32) ([]uint8, int) {
+	type node struct {
+		weight      uint64
+		symbol      int
+		left, right int
+	}
+	lengths := make([]uint8, len(freq))
+
+	var nodes []node
+	for symbol, f := range freq {
+		if f > 0 {
+			nodes = append(nodes, node{weight: uint64(f), symbol: symbol, left: -1, right: -1})
+		}
+	}
+	switch len(nodes) {
+	case 0:
+		return lengths, 0
+	case 1:
+		lengths[nodes[0].symbol] = 1
+		return lengths, 1
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+
+	// 两个队列合并：叶子按权重排好序，内部节点按生成顺序天然有序
+	leaves := len(nodes)
+	li, ii := 0, leaves
+	pick := func() int {
+		if li < leaves && (ii >= len(nodes) || nodes[li].weight <= nodes[ii].weight) {
+			li++
+			return li - 1
+		}
+		ii++
+		return ii - 1
+	}
+	for len(nodes) < 2*leaves-1 {
+		a := pick()
+		b := pick()
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+	}
+
+	maxLength := 0
+	var walk func(n, depth int)
+	walk = func(n, depth int) {
+		if nodes[n].symbol >= 0 {
+			lengths[nodes[n].symbol] = uint8(depth)
+			if depth > maxLength {
+				maxLength = depth
+			}
+			return
+		}
+		walk(nodes[n].left, depth+1)
+		walk(nodes[n].right, depth+1)
+	}
+	walk(len(nodes)-1, 0)
+	return lengths, maxLength
+}
+
+// canonicalCode 按码长生成规范编码，写出时按位反转（解码器从码的最高位开始读）
+func canonicalCode(lengths []uint8) *huffmanCode {
+	h := &huffmanCode{
+		codes: make([]uint32, len(lengths)),
+		bits:  make([]uint8, len(lengths)),
+	}
+
+	used := 0
+	var count [maxCodeLength + 1]uint32
+	for _, l := range lengths {
+		if l > 0 {
+			count[l]++
+			used++
+		}
+	}
+	// 只有一个符号时解码器不读取任何位
+	if used <= 1 {
+		return h
+	}
+
+	var next [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= maxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	for symbol, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		h.codes[symbol] = reverseBits(next[l], l)
+		h.bits[symbol] = l
+		next[l]++
+	}
+	return h
+}
+
+func reverseBits(code uint32, length uint8) uint32 {
+	var out uint32
+	for i := uint8(0); i < length; i++ {
+		out = out<<1 | code&1
+		code >>= 1
+	}
+	return out
+}
+
+// bitWriter 按VP8L要求从低位开始写入
+type bitWriter struct {
+	buf []byte
+	acc uint64
+	n   uint
+}
+
+func (b *bitWriter) write(v uint32, bits uint) {
+	if bits == 0 {
+		return
+	}
+	b.acc |= uint64(v&(1<<bits-1)) << b.n
+	b.n += bits
+	for b.n >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.n -= 8
+	}
+}
+
+func (b *bitWriter) bytes() []byte {
+	if b.n > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.n = 0, 0
+	}
+	return b.buf
+}
+
+func boolBit(v bool) uint32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func clamp255(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"1x1", fill(1, 1, func(x, y int) color.NRGBA { return color.NRGBA{12, 34, 56, 255} })},
+		{"1x1 transparent", fill(1, 1, func(x, y int) color.NRGBA { return color.NRGBA{200, 100, 50, 0} })},
+		{"solid color", fill(37, 21, func(x, y int) color.NRGBA { return color.NRGBA{0, 128, 255, 255} })},
+		{"single row", fill(300, 1, func(x, y int) color.NRGBA { return color.NRGBA{uint8(x), uint8(x * 3), 7, 255} })},
+		{"single column", fill(1, 70, func(x, y int) color.NRGBA { return color.NRGBA{9, uint8(y * 5), uint8(y), 255} })},
+		{"gradient", fill(64, 48, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 4), uint8(y * 5), uint8(x + y), 255}
+		})},
+		// 重复行和大片纯色，触发回溯引用
+		{"screenshot-like", fill(123, 45, func(x, y int) color.NRGBA {
+			if y%10 < 3 {
+				return color.NRGBA{30, 30, 30, 255}
+			}
+			return color.NRGBA{uint8(x % 17 * 15), 240, 240, 255}
+		})},
+		{"noise with alpha", fill(50, 33, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256))}
+		})},
+		{"non-nrgba source", rgbaGradient(29, 17)},
+		{"offset bounds", fill(40, 40, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 6), uint8(y * 6), 99, 255}
+		}).SubImage(image.Rect(5, 7, 31, 40))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, tt.img); err != nil {
+				t.Fatalf("EncodeWebP() error: %v", err)
+			}
+			decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("webp.Decode() error: %v", err)
+			}
+
+			src := tt.img.Bounds()
+			if got := decoded.Bounds(); got.Dx() != src.Dx() || got.Dy() != src.Dy() {
+				t.Fatalf("decoded size %v, want %dx%d", got.Size(), src.Dx(), src.Dy())
+			}
+			for y := 0; y < src.Dy(); y++ {
+				for x := 0; x < src.Dx(); x++ {
+					want := color.NRGBAModel.Convert(tt.img.At(src.Min.X+x, src.Min.Y+y))
+					got := color.NRGBAModel.Convert(decoded.At(decoded.Bounds().Min.X+x, decoded.Bounds().Min.Y+y))
+					if got != want {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPInvalidSize(t *testing.T) {
+	for _, rect := range []image.Rectangle{
+		image.Rect(0, 0, 0, 0),
+		image.Rect(0, 0, 10, 0),
+		image.Rect(0, 0, webpMaxDimension+1, 1),
+	} {
+		if err := EncodeWebP(&bytes.Buffer{}, image.NewNRGBA(rect)); err == nil {
+			t.Errorf("EncodeWebP(%v) succeeded, want error", rect)
+		}
+	}
+}
+
+func fill(width, height int, at func(x, y int) color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, at(x, y))
+		}
+	}
+	return img
+}
+
+func rgbaGradient(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 8), uint8(y * 12), 200, 255})
+		}
+	}
+	return img
+}
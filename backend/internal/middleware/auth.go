@@ -1,23 +1,39 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 	
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"techblog-api/backend/internal/config"
+	"techblog-api/backend/internal/database"
 	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/session"
 )
 
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	MFA      bool   `json:"mfa,omitempty"` // 用户已启用两步验证
 	jwt.RegisteredClaims
 }
 
+// MFAClaims 两步登录的挑战令牌，证明密码已验证通过，只能用来提交验证码
+type MFAClaims struct {
+	UserID uint `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// mfaAudience 挑战令牌的aud，访问令牌不能带有该值
+const mfaAudience = "mfa-challenge"
+
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -58,11 +74,34 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 		
-		if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims, ok := token.Claims.(*Claims); ok && token.Valid && !isMFAChallenge(claims) {
+			// jti为会话ID，会话撤销后访问令牌随即失效
+			var sess *models.Session
+			sessionID, err := strconv.ParseUint(claims.ID, 10, 64)
+			if err == nil {
+				sess, err = session.Validate(database.GetDB(), uint(sessionID), claims.UserID)
+			}
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Session has been revoked or expired",
+					Error:   "session_revoked",
+				})
+				c.Abort()
+				return
+			}
+			
+			// 最后活跃时间按间隔更新，失败不影响本次请求
+			if err := session.Touch(database.GetDB(), sess, c.ClientIP()); err != nil {
+				log.Printf("Failed to update session %d last seen: %v", sess.ID, err)
+			}
+			
 			// 将用户信息存储到上下文中
+			c.Set("session_id", sess.ID)
 			c.Set("user_id", claims.UserID)
 			c.Set("username", claims.Username)
 			c.Set("role", claims.Role)
+			c.Set("mfa", claims.MFA)
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -88,17 +127,32 @@ func AdminMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		
+		// 站点要求管理员启用两步验证时，未启用的管理员只能先去绑定
+		if mfa, _ := c.Get("mfa"); mfa != true &&
+			database.GetBoolSetting(database.GetDB(), models.SettingRequireAdminMFA, false) {
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "Two-factor authentication must be enabled to access admin features",
+				Error:   "mfa_setup_required",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
-func GenerateJWT(user *models.User, cfg *config.Config) (string, error) {
+// GenerateJWT 签发访问令牌，jti为所属会话ID，有效期较短，过期后用刷新令牌换取
+func GenerateJWT(user *models.User, sessionID uint, cfg *config.Config) (string, error) {
 	claims := &Claims{
 		UserID:   user.ID,
 		Username: user.Username,
 		Role:     user.Role,
+		MFA:      user.TOTPEnabled,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24小时过期
+			ID:        strconv.FormatUint(uint64(sessionID), 10),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "techblog-api",
 		},
@@ -108,4 +162,45 @@ func GenerateJWT(user *models.User, cfg *config.Config) (string, error) {
 	tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
 	
 	return tokenString, err
-}
\ No newline at end of file
+}
+
+// GenerateMFAChallenge 签发两步登录的挑战令牌
+func GenerateMFAChallenge(user *models.User, cfg *config.Config) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	claims := &MFAClaims{
+		UserID: user.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(buf),
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.MFAChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "techblog-api",
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+}
+
+// ParseMFAChallenge 解析挑战令牌，访问令牌不能当作挑战令牌使用
+func ParseMFAChallenge(tokenString string, cfg *config.Config) (*MFAClaims, error) {
+	claims := &MFAClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.JWTSecret), nil
+	}, jwt.WithAudience(mfaAudience), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// isMFAChallenge 挑战令牌与访问令牌使用同一个密钥签名，按aud区分
+func isMFAChallenge(claims *Claims) bool {
+	for _, audience := range claims.Audience {
+		if audience == mfaAudience {
+			return true
+		}
+	}
+	return false
+}
@@ -12,24 +12,114 @@ package models
 import (
 	"time"
 	"gorm.io/gorm"
+	"techblog-api/backend/internal/diff"
+	"techblog-api/backend/internal/markdown"
+	"techblog-api/backend/internal/textseg"
 )
 
 // BlogPost 博客文章模型
 type BlogPost struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	Title       string         `gorm:"size:200;not null" json:"title" binding:"required"`
-	Content     string         `gorm:"type:text;not null" json:"content" binding:"required"`
+	Content     string         `gorm:"type:text;not null" json:"content,omitempty" binding:"required"` // Markdown原文
+	ContentHTML string         `gorm:"type:text" json:"contentHtml,omitempty"`                           // 保存时渲染并过滤后的HTML
+	TOC         []markdown.Heading `gorm:"type:jsonb;serializer:json" json:"toc,omitempty"`              // 保存时从H2–H4提取的目录
 	Excerpt     string         `gorm:"size:500" json:"excerpt"`
-	Slug        string         `gorm:"size:200;uniqueIndex;not null" json:"slug"`
+	Slug        string         `gorm:"size:200;not null;uniqueIndex:idx_blog_posts_slug_active,where:deleted_at IS NULL" json:"slug"` // 回收站中的文章不占用slug
 	Author      string         `gorm:"size:100;not null" json:"author"`
-	Tags        string         `gorm:"size:500" json:"tags"` // JSON字符串存储标签数组
+	CategoryID  *uint          `gorm:"index" json:"categoryId"`
+	Category    *Category      `gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL" json:"category,omitempty"`
+	Tags        []Tag          `gorm:"many2many:post_tags" json:"tags"`
 	CoverImage  string         `gorm:"size:500" json:"coverImage"`
+	CoverImageSrcset []ImageSource `gorm:"-" json:"coverImageSrcset,omitempty"` // 封面为媒体库图片时的各宽度版本
 	Published   bool           `gorm:"default:false" json:"published"`
+	PublishAt   *time.Time     `gorm:"index" json:"publishAt"` // 发布时间；未发布且时间在未来时为定时发布
+	Status      string         `gorm:"-" json:"status"`        // draft, scheduled, published, trashed，查询后计算
+	TrashedAt   *time.Time     `gorm:"-" json:"trashedAt,omitempty"`
 	ReadTime    int            `gorm:"default:5" json:"readTime"` // 预估阅读时间（分钟）
 	ViewCount   int            `gorm:"default:0" json:"viewCount"`
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	// 全文检索向量，由数据库触发器维护，程序不读写
+	SearchVector string `gorm:"type:tsvector;index:idx_blog_posts_search_vector,type:gin;->:false;<-:false" json:"-"`
+	// 中日韩文本的二元分词和单字，保存前自动生成，触发器据此建立索引
+	SearchTitle   string `gorm:"type:text" json:"-"`
+	SearchExcerpt string `gorm:"type:text" json:"-"`
+	SearchContent string `gorm:"type:text" json:"-"`
+	SearchVersion int    `gorm:"not null;default:0" json:"-"` // 生成分词时的textseg.Version
+	// 搜索命中的高亮片段，仅在搜索时由查询填充
+	Highlight string `gorm:"->;-:migration" json:"highlight,omitempty"`
+	// 已审核通过的评论数，由列表查询填充
+	CommentCount int64 `gorm:"->;-:migration" json:"commentCount"`
+}
+
+// BeforeSave 保存前为标题、摘要、正文生成中文分词，并渲染正文HTML和目录
+func (p *BlogPost) BeforeSave(tx *gorm.DB) error {
+	p.SearchTitle = textseg.Tokens(p.Title)
+	p.SearchExcerpt = textseg.Tokens(p.Excerpt)
+	p.SearchContent = textseg.Tokens(p.Content)
+	p.SearchVersion = textseg.Version
+	
+	doc, err := markdown.Render(p.Content)
+	if err != nil {
+		return err
+	}
+	p.ContentHTML = doc.HTML
+	p.TOC = doc.TOC
+	return nil
+}
+
+// AfterFind 查询后计算发布状态
+func (p *BlogPost) AfterFind(tx *gorm.DB) error {
+	p.Status = p.State(time.Now())
+	if p.DeletedAt.Valid {
+		p.TrashedAt = &p.DeletedAt.Time
+	}
+	return nil
+}
+
+// State 文章在指定时间的发布状态
+func (p *BlogPost) State(now time.Time) string {
+	switch {
+	case p.DeletedAt.Valid:
+		return PostTrashed
+	case p.Published && (p.PublishAt == nil || !p.PublishAt.After(now)):
+		return PostPublished
+	case p.PublishAt != nil && p.PublishAt.After(now):
+		return PostScheduled
+	default:
+		return PostDraft
+	}
+}
+
+// 文章发布状态
+const (
+	PostDraft     = "draft"
+	PostScheduled = "scheduled"
+	PostPublished = "published"
+	PostTrashed   = "trashed"
+)
+
+// Published 查询作用域：仅包含公开可见的文章，发布时间在未来的视为未发布
+func Published(db *gorm.DB) *gorm.DB {
+	return db.Where("blog_posts.published = ? AND (blog_posts.publish_at IS NULL OR blog_posts.publish_at <= ?)", true, time.Now())
+}
+
+// Drafts 查询作用域：未发布且没有定时发布的文章
+func Drafts(db *gorm.DB) *gorm.DB {
+	return db.Where("blog_posts.published = ? AND (blog_posts.publish_at IS NULL OR blog_posts.publish_at <= ?)", false, time.Now())
+}
+
+// Scheduled 查询作用域：等待定时发布的文章
+func Scheduled(db *gorm.DB) *gorm.DB {
+	return db.Where("blog_posts.published = ? AND blog_posts.publish_at > ?", false, time.Now())
+}
+
+// Trashed 查询作用域：已移入回收站的文章
+func Trashed(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("blog_posts.deleted_at IS NOT NULL")
 }
 
 // User 用户模型（管理员）
@@ -45,6 +135,11 @@ type User struct {
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	// 两步验证：开始绑定时写入密钥，验证通过后才启用
+	TOTPSecret   string `gorm:"size:64" json:"-"`
+	TOTPEnabled  bool   `gorm:"default:false" json:"totpEnabled"`
+	TOTPLastStep int64  `gorm:"default:0" json:"-"` // 最近一次使用的时间窗口，防止验证码重放
 }
 
 // ContactMessage 联系消息模型
@@ -60,33 +155,326 @@ type ContactMessage struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
-// Category 分类模型（为后续扩展准备）
+// Category 分类模型
 type Category struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	Name        string         `gorm:"size:100;uniqueIndex;not null" json:"name"`
 	Slug        string         `gorm:"size:100;uniqueIndex;not null" json:"slug"`
 	Description string         `gorm:"size:500" json:"description"`
 	Color       string         `gorm:"size:7;default:#6366f1" json:"color"` // 十六进制颜色
-	PostCount   int            `gorm:"default:0" json:"postCount"`
+	PostCount   int            `gorm:"default:0" json:"postCount"` // 已发布文章数，随文章变更在事务中维护
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// Comment 评论模型（为后续扩展准备）
+// Tag 标签模型，名称不区分大小写唯一
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:50;not null" json:"name"`
+	Slug      string    `gorm:"size:100;uniqueIndex;not null" json:"slug"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// TagCloudItem 标签云条目
+type TagCloudItem struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"` // 已发布文章数
+}
+
+// Comment 评论模型
 type Comment struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	PostID    uint      `gorm:"not null;index" json:"postId"`
-	Post      BlogPost  `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Post      *BlogPost `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	ParentID  *uint     `gorm:"index" json:"parentId"`
+	Depth     int       `gorm:"default:0" json:"depth"` // 楼层深度，顶层评论为0
 	Author    string    `gorm:"size:100;not null" json:"author"`
 	Email     string    `gorm:"size:100;not null" json:"email"`
 	Website   string    `gorm:"size:200" json:"website"`
 	Content   string    `gorm:"type:text;not null" json:"content"`
 	Approved  bool      `gorm:"default:false" json:"approved"`
+	Status    string    `gorm:"size:20;default:pending;index" json:"status"` // pending, approved, rejected, spam
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// 评论审核状态
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+	CommentSpam     = "spam"
+)
+
+// CommentRequest 发表评论请求结构
+type CommentRequest struct {
+	Author   string `json:"author" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Website  string `json:"website" binding:"omitempty,url,max=200"`
+	Content  string `json:"content" binding:"required,max=5000"`
+	ParentID *uint  `json:"parentId"`
+}
+
+// CommentNode 公开展示的评论（不含邮箱），按楼层嵌套
+type CommentNode struct {
+	ID        uint           `json:"id"`
+	ParentID  *uint          `json:"parentId"`
+	Depth     int            `json:"depth"`
+	Author    string         `json:"author"`
+	Website   string         `json:"website"`
+	Content   string         `json:"content"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Replies   []*CommentNode `json:"replies"`
+}
+
+// CommentBulkRequest 批量审核请求结构
+type CommentBulkRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+	Action string `json:"action" binding:"required,oneof=approve reject spam delete"`
+}
+
+// CommentQuery 评论审核列表查询参数，默认返回待审核评论
+type CommentQuery struct {
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Status string `form:"status,default=pending" binding:"oneof=all pending approved rejected spam"`
+	PostID uint   `form:"post_id"`
+}
+
+// PostRevision 文章版本快照，每次创建、更新或恢复文章后记录一份
+type PostRevision struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PostID     uint      `gorm:"not null;uniqueIndex:idx_post_revisions_version" json:"postId"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_post_revisions_version" json:"version"`
+	Title      string    `gorm:"size:200;not null" json:"title"`
+	Content    string    `gorm:"type:text;not null" json:"content,omitempty"`
+	Excerpt    string    `gorm:"size:500" json:"excerpt,omitempty"`
+	Author     string    `gorm:"size:100" json:"author,omitempty"`
+	CategoryID *uint     `json:"categoryId,omitempty"`
+	Tags       []string  `gorm:"type:jsonb;serializer:json" json:"tags,omitempty"` // 标签名称
+	CoverImage string    `gorm:"size:500" json:"coverImage,omitempty"`
+	Published  bool      `json:"published"`
+	EditorID   *uint     `gorm:"index" json:"editorId"` // 操作人，来自JWT中的user_id；历史基线版本为空
+	Editor     *User     `gorm:"foreignKey:EditorID;constraint:OnDelete:SET NULL" json:"editor,omitempty"`
+	Note       string    `gorm:"size:200" json:"note,omitempty"` // 如"restored from v3"
+	CreatedAt  time.Time `gorm:"index" json:"createdAt"`
+}
+
+// PostDailyView 文章每日浏览量
+type PostDailyView struct {
+	PostID uint      `gorm:"primaryKey;autoIncrement:false" json:"postId"`
+	Day    time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views  int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// DailyViews 趋势图中的一天
+type DailyViews struct {
+	Day   string `json:"day"` // YYYY-MM-DD
+	Views int64  `json:"views"`
+}
+
+// 访问统计的细分维度
+const (
+	DimensionReferrer = "referrer"
+	DimensionCountry  = "country"
+	DimensionDevice   = "device"
+)
+
+// AnalyticsEvent 前端上报的访问事件，pageview在打开页面时发送，leave在离开时附带停留秒数
+type AnalyticsEvent struct {
+	Type     string `json:"type" binding:"omitempty,oneof=pageview leave"`
+	Path     string `json:"path" binding:"required,max=2000"`
+	Referrer string `json:"referrer" binding:"max=2000"`
+	Duration int    `json:"duration" binding:"min=0"` // 秒，仅leave事件
+}
+
+// AnalyticsSiteDay 全站每日访问量和独立访客数
+type AnalyticsSiteDay struct {
+	Day     time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views   int64     `gorm:"not null;default:0" json:"views"`
+	Uniques int64     `gorm:"not null;default:0" json:"uniques"`
+}
+
+// AnalyticsPageDay 每个页面每日的访问量、独立访客数和累计停留时间
+type AnalyticsPageDay struct {
+	Day        time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Path       string    `gorm:"primaryKey;size:300" json:"path"`
+	Views      int64     `gorm:"not null;default:0" json:"views"`
+	Uniques    int64     `gorm:"not null;default:0" json:"uniques"`
+	Seconds    int64     `gorm:"not null;default:0" json:"seconds"`    // 停留时间合计
+	TimedViews int64     `gorm:"not null;default:0" json:"timedViews"` // 上报了停留时间的访问数
+}
+
+// AnalyticsBreakdownDay 按来源域名、国家、设备类型细分的每日访问量
+type AnalyticsBreakdownDay struct {
+	Day       time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Dimension string    `gorm:"primaryKey;size:20" json:"dimension"`
+	Value     string    `gorm:"primaryKey;size:255" json:"value"`
+	Views     int64     `gorm:"not null;default:0" json:"views"`
+	Uniques   int64     `gorm:"not null;default:0" json:"uniques"`
+}
+
+// AnalyticsQuery 统计报表的日期范围，from/to为YYYY-MM-DD（UTC），默认最近30天
+type AnalyticsQuery struct {
+	From  string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To    string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Limit int    `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+// DailyUniques 访问趋势中的一天
+type DailyUniques struct {
+	Day     string `json:"day"` // YYYY-MM-DD
+	Views   int64  `json:"views"`
+	Uniques int64  `json:"uniques"`
+}
+
+// AnalyticsOverview 日期范围内的访问概况
+type AnalyticsOverview struct {
+	From          string         `json:"from"`
+	To            string         `json:"to"`
+	Views         int64          `json:"views"`
+	Uniques       int64          `json:"uniques"`       // 每日独立访客之和，跨天的同一访客会重复计算
+	AvgTimeOnPage float64        `json:"avgTimeOnPage"` // 秒
+	Daily         []DailyUniques `json:"daily"`
+}
+
+// TopPage 热门页面，文章页面附带文章信息
+type TopPage struct {
+	Path          string  `json:"path"`
+	PostID        *uint   `json:"postId,omitempty"`
+	Title         string  `json:"title,omitempty"`
+	Views         int64   `json:"views"`
+	Uniques       int64   `json:"uniques"`
+	AvgTimeOnPage float64 `json:"avgTimeOnPage"` // 秒
+}
+
+// BreakdownItem 细分维度中的一项
+type BreakdownItem struct {
+	Value   string `json:"value"`
+	Views   int64  `json:"views"`
+	Uniques int64  `json:"uniques"`
+}
+
+// Media 上传的图片，文件名为内容哈希，相同内容只保存一份
+type Media struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Hash         string    `gorm:"size:64;uniqueIndex;not null" json:"hash"` // 内容的SHA-256
+	Path         string    `gorm:"size:255;not null" json:"path"`            // 相对于上传目录的存储路径
+	URL          string    `gorm:"size:500;not null" json:"url"`
+	OriginalName string    `gorm:"size:255" json:"originalName"`
+	MimeType     string    `gorm:"size:100;not null" json:"mimeType"`
+	Size         int64     `gorm:"not null" json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	AltText      string    `gorm:"size:500" json:"altText"`
+	UploaderID   *uint     `gorm:"index" json:"uploaderId"`
+	Uploader     *User     `gorm:"foreignKey:UploaderID;constraint:OnDelete:SET NULL" json:"uploader,omitempty"`
+	CreatedAt    time.Time `gorm:"index" json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ImageSource srcset中的一项
+type ImageSource struct {
+	URL   string `json:"url"`
+	Width int    `json:"width"`
+}
+
+// MediaQuery 媒体库查询参数，search匹配原文件名和替代文本
+type MediaQuery struct {
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Search string `form:"search"`
+}
+
+// MediaUpdateRequest 修改媒体的替代文本
+type MediaUpdateRequest struct {
+	AltText string `json:"altText" binding:"max=500"`
+}
+
+// MediaReference 引用了某个媒体的文章
+type MediaReference struct {
+	PostID uint   `json:"postId"`
+	Title  string `json:"title"`
+	Slug   string `json:"slug"`
+	Status string `json:"status"`
+}
+
+// SlugRedirect 文章曾经使用过的slug，访问时重定向到当前slug
+type SlugRedirect struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Slug      string     `gorm:"size:200;uniqueIndex;not null" json:"slug"`
+	PostID    uint       `gorm:"not null;index" json:"postId"`
+	Hits      int64      `gorm:"default:0" json:"hits"`
+	LastHitAt *time.Time `json:"lastHitAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Redirect 管理员配置的任意路径重定向
+type Redirect struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	FromPath   string     `gorm:"size:500;uniqueIndex;not null" json:"fromPath"`
+	ToPath     string     `gorm:"size:500;not null" json:"toPath"`
+	StatusCode int        `gorm:"default:301" json:"statusCode"` // 301或302
+	Hits       int64      `gorm:"default:0" json:"hits"`
+	LastHitAt  *time.Time `json:"lastHitAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// RedirectRequest 重定向请求结构
+type RedirectRequest struct {
+	FromPath   string `json:"fromPath" binding:"required,startswith=/,max=500"`
+	ToPath     string `json:"toPath" binding:"required,max=500"`
+	StatusCode int    `json:"statusCode" binding:"omitempty,oneof=301 302"`
+}
+
+// RedirectTarget 重定向解析结果
+type RedirectTarget struct {
+	Location   string `json:"location"`
+	StatusCode int    `json:"statusCode"`
+}
+
+// PreviewToken 草稿预览链接，只保存令牌的SHA-256摘要
+type PreviewToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	PostID      uint       `gorm:"not null;index" json:"postId"`
+	TokenHash   string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Note        string     `gorm:"size:200" json:"note"`
+	CreatedByID *uint      `gorm:"index" json:"createdById"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// PreviewTokenRequest 创建预览链接请求结构
+type PreviewTokenRequest struct {
+	ExpiresIn int    `json:"expiresIn" binding:"omitempty,min=1,max=720"` // 有效期（小时），默认使用配置
+	Note      string `json:"note" binding:"max=200"`
+}
+
+// PreviewLink 新创建的预览链接，令牌明文只在创建时返回一次
+type PreviewLink struct {
+	PreviewToken
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// RevisionDiff 两个版本之间的差异
+type RevisionDiff struct {
+	From         int            `json:"from"`
+	To           int            `json:"to"`
+	TitleChanged bool           `json:"titleChanged"`
+	OldTitle     string         `json:"oldTitle"`
+	NewTitle     string         `json:"newTitle"`
+	Changed      []string       `json:"changed"` // 发生变化的其他字段
+	Stats        diff.Stats     `json:"stats"`
+	Lines        []diff.Line    `json:"lines"`   // 正文行级差异
+}
+
 // APIResponse 通用API响应结构
 type APIResponse struct {
 	Success bool        `json:"success"`
@@ -112,19 +500,201 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应结构
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"` // 访问令牌有效期（秒）
+	User         User   `json:"user"`
+	// 站点要求管理员启用两步验证而该用户尚未启用，启用前无法访问管理接口
+	MFASetupRequired bool `json:"mfaSetupRequired,omitempty"`
+}
+
+// Session 登录会话，一次登录对应一个会话，刷新令牌轮换时会话不变
+type Session struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"userId"`
+	UserAgent     string     `gorm:"size:500" json:"userAgent"`
+	IP            string     `gorm:"size:64" json:"ip"` // 只保存网段，如203.0.113.0/24
+	LastSeenAt    time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"lastSeenAt"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expiresAt"` // 最新刷新令牌的过期时间
+	RevokedAt     *time.Time `gorm:"index" json:"revokedAt,omitempty"`
+	RevokedReason string     `gorm:"size:50" json:"revokedReason,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+
+	Current bool `gorm:"-" json:"current"` // 是否为发起请求的会话
+}
+
+// RefreshToken 刷新令牌，只保存哈希；同一会话内换发的令牌构成一个令牌族
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	SessionID uint       `gorm:"not null;index" json:"sessionId"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"` // 已换发新令牌，再次使用视为泄露
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	CodeHash  string     `gorm:"size:64;not null;index" json:"-"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// PasswordResetToken 找回密码的重置令牌，只保存哈希，使用一次或签发新令牌后作废
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	IP        string     `gorm:"size:64" json:"ip"` // 申请重置的请求来源
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// 登录失败计数的维度
+const (
+	LoginScopeUser = "user"
+	LoginScopeIP   = "ip"
+)
+
+// LoginThrottle 某个用户名或IP的连续登录失败计数
+type LoginThrottle struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Scope        string     `gorm:"size:10;not null;uniqueIndex:idx_login_throttle_key" json:"scope"`
+	Key          string     `gorm:"size:100;not null;uniqueIndex:idx_login_throttle_key" json:"key"`
+	Failures     int        `gorm:"not null;default:0" json:"failures"`
+	LastFailedAt time.Time  `gorm:"index" json:"lastFailedAt"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty"`
+	Pending      int        `gorm:"not null;default:0" json:"pending"` // 已放行但还没有结果的尝试数
+	AttemptedAt  *time.Time `json:"attemptedAt,omitempty"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// LoginLockout 锁定记录，每次因失败过多被锁定时写入一条
+type LoginLockout struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Scope        string     `gorm:"size:10;not null;index" json:"scope"`
+	Key          string     `gorm:"size:100;not null;index" json:"key"`
+	Failures     int        `json:"failures"`
+	IP           string     `gorm:"size:64" json:"ip"` // 触发锁定的请求来源
+	LockedUntil  time.Time  `gorm:"not null;index" json:"lockedUntil"`
+	UnlockedAt   *time.Time `json:"unlockedAt,omitempty"`
+	UnlockedByID *uint      `json:"unlockedById,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// LoginChallenge 两步登录挑战令牌的使用情况，按令牌ID记录，多个副本共享
+type LoginChallenge struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TokenID   string     `gorm:"size:64;not null;uniqueIndex" json:"tokenId"`
+	Failures  int        `gorm:"not null;default:0" json:"failures"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// LockoutQuery 锁定记录查询参数
+type LockoutQuery struct {
+	Page   int  `form:"page,default=1" binding:"min=1"`
+	Limit  int  `form:"limit,default=20" binding:"min=1,max=100"`
+	Active bool `form:"active"` // 只看仍在锁定中的记录
+}
+
+// UnlockRequest 解除锁定请求结构，用户名和IP至少一个
+type UnlockRequest struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+// Setting 可由管理员在运行时修改的站点设置
+type Setting struct {
+	Key       string    `gorm:"primaryKey;size:100" json:"key"`
+	Value     string    `gorm:"size:1000" json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// 设置项
+const (
+	SettingRequireAdminMFA = "security.require_admin_mfa" // 要求所有管理员启用两步验证
+)
+
+// SecuritySettings 安全设置
+type SecuritySettings struct {
+	RequireAdminMFA bool `json:"requireAdminMfa"`
+}
+
+// MFAChallenge 密码验证通过但还需要两步验证时的登录响应
+type MFAChallenge struct {
+	MFARequired    bool   `json:"mfaRequired"`
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresIn      int    `json:"expiresIn"` // 挑战令牌有效期（秒）
+}
+
+// MFALoginRequest 两步登录第二步请求结构，验证码和恢复码二选一
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+// MFACodeRequest 需要当前验证码的操作
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFADisableRequest 关闭两步验证请求结构，验证码和恢复码二选一
+type MFADisableRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// ForgotPasswordRequest 找回密码请求结构
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+// ResetPasswordRequest 重置密码请求结构，令牌来自重置邮件中的链接
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
+// RefreshTokenRequest 刷新令牌请求结构
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// TokenResponse 刷新令牌响应结构，刷新令牌每次都会更换
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
 }
 
 // BlogPostRequest 博客文章请求结构
 type BlogPostRequest struct {
 	Title      string   `json:"title" binding:"required"`
+	Slug       string   `json:"slug" binding:"max=200"` // 为空时标题变化会重新生成slug，旧slug自动重定向
 	Content    string   `json:"content" binding:"required"`
 	Excerpt    string   `json:"excerpt"`
 	Author     string   `json:"author" binding:"required"`
-	Tags       []string `json:"tags"`
+	CategoryID *uint    `json:"categoryId"`
+	Tags       []string `json:"tags" binding:"max=20,dive,max=50"`
 	CoverImage string   `json:"coverImage"`
 	Published  bool     `json:"published"`
+	PublishAt  *time.Time `json:"publishAt"` // 未来时间表示定时发布，此时忽略published
+}
+
+// CategoryRequest 分类请求结构
+type CategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Slug        string `json:"slug" binding:"max=100"`
+	Description string `json:"description" binding:"max=500"`
+	Color       string `json:"color" binding:"omitempty,hexcolor"`
 }
 
 // BlogPostQuery 博客文章查询参数
@@ -132,11 +702,39 @@ type BlogPostQuery struct {
 	Page      int    `form:"page,default=1"`
 	Limit     int    `form:"limit,default=10"`
 	Search    string `form:"search"`
-	Tag       string `form:"tag"`
+	Tag       string `form:"tag"`                     // 标签slug或名称，多个用逗号分隔
+	TagMode   string `form:"tag_mode,default=any" binding:"oneof=any all"`  // any: 命中任一标签, all: 同时包含全部标签
+	Category  string `form:"category"` // 分类slug或ID
 	Author    string `form:"author"`
-	Published *bool  `form:"published"`
-	Sort      string `form:"sort,default=created_at"`
-	Order     string `form:"order,default=desc"`
+	Sort      string `form:"sort,default=created_at"` // created_at, updated_at, view_count, read_time, relevance
+	Order     string `form:"order,default=desc" binding:"oneof=asc desc ASC DESC"`
+}
+
+// AdminPostQuery 管理后台文章查询参数，可按状态过滤
+type AdminPostQuery struct {
+	BlogPostQuery
+	Status string `form:"status,default=all" binding:"oneof=all draft scheduled published trashed"`
+}
+
+// SearchQuery 全文搜索查询参数
+type SearchQuery struct {
+	Q     string `form:"q" binding:"required"`
+	Page  int    `form:"page,default=1"`
+	Limit int    `form:"limit,default=10"`
+}
+
+// SearchResult 全文搜索结果
+type SearchResult struct {
+	ID             uint      `json:"id"`
+	Title          string    `json:"title"`
+	Slug           string    `json:"slug"`
+	Excerpt        string    `json:"excerpt"`
+	Author         string    `json:"author"`
+	CoverImage     string    `json:"coverImage"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Rank           float64   `json:"rank"`
+	TitleHighlight string    `json:"titleHighlight"`
+	Fragments      []string  `json:"fragments"`
 }
 
 // SponsorOrder 赞助订单模型
@@ -0,0 +1,141 @@
+/*
+开发心理过程：
+1. 重置令牌是随机串，数据库只保存SHA-256哈希，数据库泄露也无法用来重置密码
+2. 令牌只能用一次，有效期很短；重新申请时之前的令牌全部作废，邮箱里只有最新的链接有效
+3. 重置密码说明旧密码可能已经泄露，重置后撤销该用户的全部会话，已登录的设备需要重新登录
+4. 同一用户短时间内重复申请时不再签发，防止被人用来反复给用户发邮件
+*/
+
+package passwordreset
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+	"techblog-api/backend/internal/session"
+)
+
+var (
+	// ErrInvalidToken 令牌不存在或已使用
+	ErrInvalidToken = errors.New("invalid reset token")
+	// ErrExpired 令牌已过期
+	ErrExpired = errors.New("reset token expired")
+	// ErrTooSoon 距上次申请不足MinInterval
+	ErrTooSoon = errors.New("reset requested too recently")
+)
+
+// MinInterval 同一用户两次申请之间的最短间隔
+const MinInterval = time.Minute
+
+// purgeAfter 过期超过这么久的令牌在签发新令牌时顺带删除
+const purgeAfter = 24 * time.Hour
+
+// Issue 为用户签发重置令牌，之前未使用的令牌全部作废
+func Issue(db *gorm.DB, userID uint, ip string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 锁住用户行，同一用户的并发申请依次处理，间隔检查才可靠
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&models.User{}, userID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var recent int64
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND created_at > ?", userID, now.Add(-MinInterval)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return ErrTooSoon
+		}
+
+		if err := invalidate(tx, userID, now); err != nil {
+			return err
+		}
+		if err := tx.Where("expires_at < ?", now.Add(-purgeAfter)).
+			Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			TokenHash: HashToken(token),
+			IP:        ip,
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Reset 用令牌把密码改为passwordHash，令牌作废并撤销用户全部会话，返回对应的用户
+func Reset(db *gorm.DB, token, passwordHash string) (*models.User, error) {
+	var user models.User
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 锁住令牌行，同一令牌的并发使用只有一个能成功
+		var reset models.PasswordResetToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", HashToken(token)).
+			First(&reset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if reset.UsedAt != nil {
+			return ErrInvalidToken
+		}
+		now := time.Now()
+		if now.After(reset.ExpiresAt) {
+			return ErrExpired
+		}
+
+		if err := tx.Where("id = ? AND active = ?", reset.UserID, true).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if err := tx.Model(&user).Update("password", passwordHash).Error; err != nil {
+			return err
+		}
+		if err := invalidate(tx, user.ID, now); err != nil {
+			return err
+		}
+		_, err := session.RevokeAll(tx, user.ID, session.ReasonPasswordReset)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// HashToken 重置令牌的SHA-256哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// invalidate 作废用户全部未使用的令牌
+func invalidate(tx *gorm.DB, userID uint, now time.Time) error {
+	return tx.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", now).Error
+}
@@ -0,0 +1,101 @@
+/*
+开发心理过程：
+1. 定时发布的文章以published=false + 未来的publish_at保存，到点后由后台任务改为已发布
+2. 状态完全保存在数据库中，服务重启后第一次检查就会补发错过的文章
+3. 多副本部署时用事务级advisory lock保证同一时刻只有一个实例在发布
+4. 更新条件本身包含published=false，即使锁失效也不会重复发布
+5. 先锁分类再改文章，与文章接口的加锁顺序一致，避免死锁
+6. 回收站清理任务沿用同样的循环和锁机制
+*/
+
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/database"
+	"techblog-api/backend/internal/models"
+)
+
+// publishLockKey 定时发布任务使用的advisory lock键
+const publishLockKey int64 = 0x7465636870756221
+
+// Publisher 定时发布后台任务
+type Publisher struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+// NewPublisher 创建定时发布任务，interval为检查间隔
+func NewPublisher(db *gorm.DB, interval time.Duration) *Publisher {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Publisher{db: db, interval: interval}
+}
+
+// Run 立即检查一次，之后按间隔检查，直到ctx取消
+func (p *Publisher) Run(ctx context.Context) {
+	every(ctx, p.interval, func() {
+		if n, err := p.PublishDue(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Scheduled publishing failed: %v", err)
+			}
+		} else if n > 0 {
+			log.Printf("Published %d scheduled posts", n)
+		}
+	})
+}
+
+// PublishDue 发布所有到期的定时文章，返回发布数量；其他实例正在发布时直接返回0
+func (p *Publisher) PublishDue(ctx context.Context) (int, error) {
+	var published int
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if locked, err := tryLock(tx, publishLockKey); err != nil || !locked {
+			return err
+		}
+
+		now := time.Now()
+		due := tx.Model(&models.BlogPost{}).
+			Where("published = ? AND publish_at <= ?", false, now)
+
+		var categoryIDs []uint
+		if err := due.Session(&gorm.Session{}).Distinct("category_id").
+			Where("category_id IS NOT NULL").Pluck("category_id", &categoryIDs).Error; err != nil {
+			return err
+		}
+		if len(categoryIDs) > 0 {
+			var locked []models.Category
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Order("id").Find(&locked, categoryIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		// UpdateColumns不触发保存钩子，正文无需重新渲染
+		result := due.Session(&gorm.Session{}).UpdateColumns(map[string]interface{}{
+			"published":  true,
+			"updated_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		published = int(result.RowsAffected)
+		if published == 0 {
+			return nil
+		}
+
+		ids := make([]*uint, len(categoryIDs))
+		for i := range categoryIDs {
+			ids[i] = &categoryIDs[i]
+		}
+		return database.SyncCategoryPostCounts(tx, ids...)
+	})
+
+	return published, err
+}
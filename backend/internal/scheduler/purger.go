@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/database"
+)
+
+// purgeLockKey 回收站清理任务使用的advisory lock键
+const purgeLockKey int64 = 0x7465636870757267
+
+// TrashPurger 定期永久删除超过保留期的回收站文章
+type TrashPurger struct {
+	db        *gorm.DB
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewTrashPurger 创建回收站清理任务，retention为保留时长
+func NewTrashPurger(db *gorm.DB, retention, interval time.Duration) *TrashPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &TrashPurger{db: db, retention: retention, interval: interval}
+}
+
+// Run 立即清理一次，之后按间隔清理，直到ctx取消；保留时长不大于0时不清理
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.retention <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	every(ctx, p.interval, func() {
+		if n, err := p.PurgeExpired(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Trash purge failed: %v", err)
+			}
+		} else if n > 0 {
+			log.Printf("Purged %d posts from trash", n)
+		}
+	})
+}
+
+// PurgeExpired 永久删除超过保留期的文章，其他实例正在清理时直接返回0
+func (p *TrashPurger) PurgeExpired(ctx context.Context) (int, error) {
+	var purged int
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if locked, err := tryLock(tx, purgeLockKey); err != nil || !locked {
+			return err
+		}
+
+		n, err := database.PurgeTrash(tx, time.Now().Add(-p.retention))
+		purged = n
+		return err
+	})
+
+	return purged, err
+}
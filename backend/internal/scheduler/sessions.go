@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/session"
+)
+
+// sessionPurgeLockKey 会话清理任务使用的advisory lock键
+const sessionPurgeLockKey int64 = 0x7465636873657373
+
+// SessionPurger 定期删除早已过期或撤销的会话及刷新令牌
+type SessionPurger struct {
+	db        *gorm.DB
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewSessionPurger 创建会话清理任务，过期或撤销超过retention的会话会被删除
+func NewSessionPurger(db *gorm.DB, retention, interval time.Duration) *SessionPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &SessionPurger{db: db, retention: retention, interval: interval}
+}
+
+// Run 立即清理一次，之后按间隔清理，直到ctx取消
+func (p *SessionPurger) Run(ctx context.Context) {
+	every(ctx, p.interval, func() {
+		if n, err := p.PurgeExpired(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Session purge failed: %v", err)
+			}
+		} else if n > 0 {
+			log.Printf("Purged %d expired sessions", n)
+		}
+	})
+}
+
+// PurgeExpired 删除超过保留期的会话，其他实例正在清理时直接返回0
+func (p *SessionPurger) PurgeExpired(ctx context.Context) (int64, error) {
+	var purged int64
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if locked, err := tryLock(tx, sessionPurgeLockKey); err != nil || !locked {
+			return err
+		}
+
+		n, err := session.Purge(tx, time.Now().Add(-p.retention))
+		purged = n
+		return err
+	})
+
+	return purged, err
+}
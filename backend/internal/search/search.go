@@ -0,0 +1,126 @@
+/*
+开发心理过程：
+1. 用PostgreSQL原生全文检索替代ILIKE全表扫描
+2. tsvector列由触发器维护，权重：标题(A) > 摘要(B) > 正文(C)
+3. 查询使用websearch_to_tsquery，支持"短语"、-排除、OR语法
+4. 中文按textseg的二元切分建立索引，查询语句用同样的规则改写
+5. 高亮片段先用控制字符做标记，转义后再替换成<mark>，避免正文中的HTML注入
+*/
+
+package search
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/textseg"
+)
+
+// DefaultLanguage 默认的全文检索配置（不做词干提取，中英文混排更稳妥）
+const DefaultLanguage = "simple"
+
+// 高亮标记使用正文中几乎不可能出现的控制字符，最后统一转换成<mark>
+const (
+	highlightStart    = "\x02"
+	highlightStop     = "\x03"
+	fragmentDelimiter = "\x1f"
+)
+
+var languagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// NormalizeLanguage 校验全文检索配置名，非法时回退到默认配置
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if !languagePattern.MatchString(lang) {
+		return DefaultLanguage
+	}
+	return lang
+}
+
+// Migrate 创建维护search_vector的触发器并回填历史数据
+func Migrate(db *gorm.DB, lang string) error {
+	lang = NormalizeLanguage(lang)
+
+	statements := []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION blog_posts_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('%[1]s', coalesce(NEW.title, '') || ' ' || coalesce(NEW.search_title, '')), 'A') ||
+		setweight(to_tsvector('%[1]s', coalesce(NEW.excerpt, '') || ' ' || coalesce(NEW.search_excerpt, '')), 'B') ||
+		setweight(to_tsvector('%[1]s', coalesce(NEW.content, '') || ' ' || coalesce(NEW.search_content, '')), 'C');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`, lang),
+		`DROP TRIGGER IF EXISTS blog_posts_search_vector_trigger ON blog_posts`,
+		`CREATE TRIGGER blog_posts_search_vector_trigger
+	BEFORE INSERT OR UPDATE OF title, excerpt, content, search_title, search_excerpt, search_content ON blog_posts
+	FOR EACH ROW EXECUTE FUNCTION blog_posts_search_vector_update()`,
+		// 触发器只在标题/摘要/正文变化时执行，这里让旧数据补齐索引
+		`UPDATE blog_posts SET title = title WHERE search_vector IS NULL`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("search migration failed: %w", err)
+		}
+	}
+
+	log.Printf("Full-text search ready (language: %s)", lang)
+	return nil
+}
+
+// Query 将用户输入改写为与索引一致的查询语句
+func Query(q string) string {
+	return textseg.Query(q)
+}
+
+// Match 追加全文检索条件，q需先经过Query改写
+func Match(tx *gorm.DB, lang, q string) *gorm.DB {
+	return tx.Where("blog_posts.search_vector @@ websearch_to_tsquery(?::regconfig, ?)", lang, q)
+}
+
+// OrderByRank 按相关度排序，相关度相同时新文章优先
+func OrderByRank(tx *gorm.DB, lang, q string) *gorm.DB {
+	return tx.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                "ts_rank_cd(blog_posts.search_vector, websearch_to_tsquery(?::regconfig, ?)) DESC, blog_posts.created_at DESC",
+		Vars:               []interface{}{lang, q},
+		WithoutParentheses: true,
+	}})
+}
+
+// HeadlineOptions 生成ts_headline选项，maxFragments为0时返回整段高亮
+func HeadlineOptions(maxFragments int) string {
+	return fmt.Sprintf(`StartSel="%s", StopSel="%s", FragmentDelimiter="%s", MaxFragments=%d, MaxWords=30, MinWords=10`,
+		highlightStart, highlightStop, fragmentDelimiter, maxFragments)
+}
+
+// HeadlineExpr 返回ts_headline表达式，column为需要高亮的列
+func HeadlineExpr(column string) string {
+	return fmt.Sprintf("ts_headline(?::regconfig, coalesce(blog_posts.%s, ''), websearch_to_tsquery(?::regconfig, ?), ?)", column)
+}
+
+// Highlight 将ts_headline结果转义为安全的HTML，命中词用<mark>包裹
+func Highlight(raw string) string {
+	return strings.Join(Fragments(raw), " … ")
+}
+
+// Fragments 将ts_headline结果拆分为多个已转义的高亮片段
+func Fragments(raw string) []string {
+	var fragments []string
+	for _, part := range strings.Split(raw, fragmentDelimiter) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		escaped := html.EscapeString(part)
+		escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+		escaped = strings.ReplaceAll(escaped, highlightStop, "</mark>")
+		fragments = append(fragments, escaped)
+	}
+	return fragments
+}
@@ -0,0 +1,261 @@
+/*
+开发心理过程：
+1. 访问令牌有效期很短，只负责鉴权；保持登录靠刷新令牌，刷新令牌记录在数据库中才能撤销
+2. 刷新令牌是随机串，数据库只保存SHA-256哈希，数据库泄露也无法直接使用
+3. 每次刷新都换发新令牌并作废旧令牌，同一会话换发的令牌构成一个令牌族
+4. 已经用过的令牌再次出现，说明令牌被复制过，无法判断哪一方是合法用户，直接撤销整个会话
+5. 访问令牌的jti记录会话ID，会话撤销后尚未过期的访问令牌也立即失效
+6. 最后活跃时间用于会话列表，每次请求都写库代价太大，间隔一段时间才更新一次
+7. 会话列表只需要看出大致位置，IP只保存网段
+*/
+
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+)
+
+var (
+	// ErrInvalidToken 刷新令牌不存在
+	ErrInvalidToken = errors.New("invalid refresh token")
+	// ErrTokenReused 刷新令牌已经使用过，会话已被撤销
+	ErrTokenReused = errors.New("refresh token reused")
+	// ErrRevoked 会话已撤销
+	ErrRevoked = errors.New("session revoked")
+	// ErrExpired 会话或刷新令牌已过期
+	ErrExpired = errors.New("session expired")
+)
+
+// 会话撤销原因
+const (
+	ReasonLogout        = "logout"
+	ReasonLogoutAll     = "logout_all"
+	ReasonReuse         = "token_reuse"
+	ReasonInactive      = "user_inactive"
+	ReasonRevoked       = "revoked"
+	ReasonPasswordReset = "password_reset"
+)
+
+// TouchInterval 最后活跃时间的更新间隔
+const TouchInterval = time.Minute
+
+// Create 为用户创建会话并签发第一个刷新令牌，ttl为刷新令牌有效期
+func Create(db *gorm.DB, userID uint, userAgent, ip string, ttl time.Duration) (*models.Session, string, error) {
+	now := time.Now()
+	sess := models.Session{
+		UserID:     userID,
+		UserAgent:  truncate(userAgent, 500),
+		IP:         CoarseIP(ip),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	var token string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sess).Error; err != nil {
+			return err
+		}
+		var err error
+		token, err = issue(tx, sess.ID, sess.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &sess, token, nil
+}
+
+// Rotate 用刷新令牌换取新的刷新令牌，旧令牌随即作废
+// 旧令牌被再次使用时撤销整个会话并返回ErrTokenReused
+func Rotate(db *gorm.DB, token string, ttl time.Duration) (*models.Session, string, error) {
+	var sess models.Session
+	var next string
+	var reused bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 锁住令牌行，同一令牌的并发刷新只有一个能成功
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", HashToken(token)).
+			First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if err := tx.First(&sess, current.SessionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if sess.RevokedAt != nil {
+			return ErrRevoked
+		}
+
+		now := time.Now()
+		if current.UsedAt != nil {
+			reused = true
+			return revoke(tx.Where("id = ?", sess.ID), ReasonReuse)
+		}
+		if now.After(current.ExpiresAt) {
+			return ErrExpired
+		}
+
+		if err := tx.Model(&current).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		sess.ExpiresAt = now.Add(ttl)
+		if err := tx.Model(&sess).Update("expires_at", sess.ExpiresAt).Error; err != nil {
+			return err
+		}
+		var err error
+		next, err = issue(tx, sess.ID, sess.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if reused {
+		return nil, "", ErrTokenReused
+	}
+	return &sess, next, nil
+}
+
+// Validate 检查访问令牌对应的会话仍然有效
+func Validate(db *gorm.DB, sessionID, userID uint) (*models.Session, error) {
+	var sess models.Session
+	if err := db.First(&sess, sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevoked
+		}
+		return nil, err
+	}
+	if sess.UserID != userID || sess.RevokedAt != nil {
+		return nil, ErrRevoked
+	}
+	if time.Now().After(sess.ExpiresAt) {
+		return nil, ErrExpired
+	}
+	return &sess, nil
+}
+
+// Touch 更新会话的最后活跃时间和网段，距上次更新不足TouchInterval时跳过
+// 条件更新保证多个实例同时处理请求时只写一次
+func Touch(db *gorm.DB, sess *models.Session, ip string) error {
+	now := time.Now()
+	if now.Sub(sess.LastSeenAt) < TouchInterval {
+		return nil
+	}
+	return db.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", sess.ID, now.Add(-TouchInterval)).
+		Updates(map[string]interface{}{"last_seen_at": now, "ip": CoarseIP(ip)}).Error
+}
+
+// List 用户仍然有效的会话，最近活跃的在前
+func List(db *gorm.DB, userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC, id DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke 撤销单个会话
+func Revoke(db *gorm.DB, sessionID uint, reason string) error {
+	return revoke(db.Where("id = ?", sessionID), reason)
+}
+
+// RevokeAll 撤销用户的全部会话，返回撤销的数量
+func RevokeAll(db *gorm.DB, userID uint, reason string) (int64, error) {
+	result := db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// Purge 删除在before之前已过期或已撤销的会话及其刷新令牌
+func Purge(db *gorm.DB, before time.Time) (int64, error) {
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&models.Session{}).Select("id").
+			Where("expires_at < ? OR revoked_at < ?", before, before)
+		if err := tx.Where("session_id IN (?)", stale).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("expires_at < ? OR revoked_at < ?", before, before).Delete(&models.Session{})
+		purged = result.RowsAffected
+		if result.Error != nil {
+			return result.Error
+		}
+		// 仍然有效的会话里，过期的旧令牌不会再被接受，也不需要用来检测重复使用
+		return tx.Where("expires_at < ?", before).Delete(&models.RefreshToken{}).Error
+	})
+	return purged, err
+}
+
+// HashToken 刷新令牌的SHA-256哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// revoke 撤销query匹配且尚未撤销的会话
+func revoke(query *gorm.DB, reason string) error {
+	return query.Model(&models.Session{}).
+		Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// issue 为会话签发新的刷新令牌
+func issue(tx *gorm.DB, sessionID uint, expiresAt time.Time) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	err := tx.Create(&models.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: HashToken(token),
+		ExpiresAt: expiresAt,
+	}).Error
+	return token, err
+}
+
+// CoarseIP 把IP地址截断为网段，IPv4保留/24，IPv6保留/48
+func CoarseIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// truncate 按字符截断，避免超出列宽
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
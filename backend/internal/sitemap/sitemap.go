@@ -0,0 +1,233 @@
+/*
+开发心理过程：
+1. 站点地图包含首页、博客列表、已发布文章、分类和标签
+2. URL数量超过分片大小时，sitemap.xml变为索引，分片放在sitemaps/N.xml
+3. 生成逻辑与HTTP无关，接口和部署时的命令行工具共用
+4. robots.txt由配置生成，并指向站点地图
+*/
+
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"techblog-api/backend/internal/models"
+)
+
+// MaxURLsPerFile 协议规定的单个站点地图最大URL数
+const MaxURLsPerFile = 50000
+
+// IndexFile 入口文件名
+const IndexFile = "sitemap.xml"
+
+// URL 站点地图中的一个地址
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+// Collect 从数据库收集所有公开页面地址
+func Collect(db *gorm.DB, siteURL string) ([]URL, error) {
+	base := strings.TrimRight(siteURL, "/")
+	var urls []URL
+
+	// 文章
+	var posts []struct {
+		Slug      string
+		UpdatedAt time.Time
+	}
+	if err := db.Model(&models.BlogPost{}).Scopes(models.Published).
+		Select("blog_posts.slug, blog_posts.updated_at").
+		Order("blog_posts.updated_at DESC").
+		Scan(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to collect posts: %w", err)
+	}
+
+	var newest time.Time
+	if len(posts) > 0 {
+		newest = posts[0].UpdatedAt
+	}
+
+	urls = append(urls,
+		URL{Loc: base + "/", LastMod: newest, ChangeFreq: "daily", Priority: 1.0},
+		URL{Loc: base + "/blog", LastMod: newest, ChangeFreq: "daily", Priority: 0.9},
+	)
+	for _, post := range posts {
+		urls = append(urls, URL{Loc: base + "/blog/" + post.Slug, LastMod: post.UpdatedAt, ChangeFreq: "weekly", Priority: 0.8})
+	}
+
+	// 分类与标签，最后修改时间取其下最新文章的更新时间
+	var groups []struct {
+		Slug    string
+		LastMod time.Time
+	}
+	if err := db.Model(&models.BlogPost{}).Scopes(models.Published).
+		Select("categories.slug, MAX(blog_posts.updated_at) AS last_mod").
+		Joins("JOIN categories ON categories.id = blog_posts.category_id AND categories.deleted_at IS NULL").
+		Group("categories.slug").Order("categories.slug").
+		Scan(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to collect categories: %w", err)
+	}
+	for _, category := range groups {
+		urls = append(urls, URL{Loc: base + "/blog?category=" + category.Slug, LastMod: category.LastMod, ChangeFreq: "weekly", Priority: 0.5})
+	}
+
+	groups = nil
+	if err := db.Model(&models.BlogPost{}).Scopes(models.Published).
+		Select("tags.slug, MAX(blog_posts.updated_at) AS last_mod").
+		Joins("JOIN post_tags ON post_tags.blog_post_id = blog_posts.id").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Group("tags.slug").Order("tags.slug").
+		Scan(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to collect tags: %w", err)
+	}
+	for _, tag := range groups {
+		urls = append(urls, URL{Loc: base + "/blog?tag=" + tag.Slug, LastMod: tag.LastMod, ChangeFreq: "weekly", Priority: 0.5})
+	}
+
+	return urls, nil
+}
+
+// ChunkFile 第n个分片的文件名（从1开始）
+func ChunkFile(n int) string {
+	return fmt.Sprintf("sitemaps/%d.xml", n)
+}
+
+// Files 生成全部站点地图文件，键为相对路径
+// URL数量不超过chunkSize时只有sitemap.xml；否则sitemap.xml为索引文件
+func Files(urls []URL, chunkSize int, siteURL string) (map[string][]byte, error) {
+	if chunkSize <= 0 || chunkSize > MaxURLsPerFile {
+		chunkSize = MaxURLsPerFile
+	}
+
+	files := make(map[string][]byte)
+	if len(urls) <= chunkSize {
+		body, err := renderURLSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		files[IndexFile] = body
+		return files, nil
+	}
+
+	base := strings.TrimRight(siteURL, "/")
+	var entries []indexEntry
+	for n, start := 1, 0; start < len(urls); n, start = n+1, start+chunkSize {
+		end := start + chunkSize
+		if end > len(urls) {
+			end = len(urls)
+		}
+		chunk := urls[start:end]
+
+		body, err := renderURLSet(chunk)
+		if err != nil {
+			return nil, err
+		}
+		files[ChunkFile(n)] = body
+		entries = append(entries, indexEntry{Loc: base + "/" + ChunkFile(n), LastMod: formatTime(newestOf(chunk))})
+	}
+
+	body, err := marshal(sitemapIndex{XMLNS: namespace, Sitemaps: entries})
+	if err != nil {
+		return nil, err
+	}
+	files[IndexFile] = body
+	return files, nil
+}
+
+// Gzip 压缩站点地图文件
+func Gzip(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Robots 生成robots.txt，disallow为禁止抓取的路径
+func Robots(siteURL string, disallow []string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range disallow {
+		if p = strings.TrimSpace(p); p != "" {
+			b.WriteString("Disallow: " + p + "\n")
+		}
+	}
+	b.WriteString("\nSitemap: " + strings.TrimRight(siteURL, "/") + "/" + IndexFile + "\n")
+	return b.String()
+}
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []indexEntry `xml:"sitemap"`
+}
+
+type indexEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func renderURLSet(urls []URL) ([]byte, error) {
+	set := urlSet{XMLNS: namespace}
+	for _, u := range urls {
+		entry := urlEntry{Loc: u.Loc, LastMod: formatTime(u.LastMod), ChangeFreq: u.ChangeFreq}
+		if u.Priority > 0 {
+			entry.Priority = fmt.Sprintf("%.1f", u.Priority)
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	return marshal(set)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newestOf(urls []URL) time.Time {
+	var newest time.Time
+	for _, u := range urls {
+		if u.LastMod.After(newest) {
+			newest = u.LastMod
+		}
+	}
+	return newest
+}
@@ -0,0 +1,204 @@
+/*
+开发心理过程：
+1. 纯中文标题原来会生成空slug，改为汉字转拼音
+2. 其他文字先查自定义音译表，再查内置的西里尔/希腊字母表，最后去掉变音符号
+3. 限制最大长度，截断时尽量落在连字符处
+4. 冲突时依次追加-2、-3，结果可预期，不再拼接时间戳
+*/
+
+package slug
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+	"techblog-api/backend/internal/textseg"
+)
+
+// DefaultMaxLength 默认slug最大长度
+const DefaultMaxLength = 80
+
+// MinMaxLength 允许配置的最小长度，需要容纳冲突后缀（最长为-999）
+const MinMaxLength = 8
+
+// Fallback 标题中没有任何可用字符时使用的slug
+const Fallback = "post"
+
+// maxAttempts 冲突后缀的最大尝试次数
+const maxAttempts = 1000
+
+// ErrExhausted 冲突后缀尝试次数用尽
+var ErrExhausted = errors.New("slug: no available suffix")
+
+// Options slug生成配置
+type Options struct {
+	MaxLength        int             // 最大长度，<=0时使用默认值，小于MinMaxLength时按MinMaxLength
+	Transliterations map[rune]string // 自定义音译表，优先于内置规则
+}
+
+// Generator slug生成器
+type Generator struct {
+	maxLength        int
+	transliterations map[rune]string
+	pinyinArgs       pinyin.Args
+}
+
+// New 创建slug生成器
+func New(opts Options) *Generator {
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = DefaultMaxLength
+	}
+	if opts.MaxLength < MinMaxLength {
+		opts.MaxLength = MinMaxLength
+	}
+	return &Generator{
+		maxLength:        opts.MaxLength,
+		transliterations: opts.Transliterations,
+		pinyinArgs:       pinyin.NewArgs(),
+	}
+}
+
+// ParseTransliterations 解析音译表配置，格式如 "ä=ae,ö=oe,ß=ss"
+func ParseTransliterations(spec string) map[rune]string {
+	table := make(map[rune]string)
+	for _, pair := range strings.Split(spec, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		runes := []rune(from)
+		if !ok || len(runes) != 1 {
+			continue
+		}
+		table[unicode.ToLower(runes[0])] = strings.ToLower(strings.TrimSpace(to))
+	}
+	return table
+}
+
+// Make 根据标题生成slug
+func (g *Generator) Make(title string) string {
+	var words []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	// 音译结果可能包含非字母数字字符，统一过滤
+	appendText := func(s string) {
+		for _, r := range s {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				word.WriteRune(r)
+			} else {
+				flush()
+			}
+		}
+	}
+
+	for _, seg := range textseg.Split(title) {
+		for _, r := range seg.Text {
+			r = unicode.ToLower(r)
+			if custom, ok := g.transliterations[r]; ok {
+				appendText(custom)
+				continue
+			}
+
+			// 每个汉字单独成词，如"全文检索" -> quan-wen-jian-suo
+			if seg.CJK && textseg.IsHan(r) {
+				flush()
+				if py := pinyin.SinglePinyin(r, g.pinyinArgs); len(py) > 0 {
+					appendText(py[0])
+					flush()
+				}
+				continue
+			}
+
+			appendText(transliterate(r))
+		}
+	}
+	flush()
+
+	return g.truncate(strings.Join(words, "-"), g.maxLength)
+}
+
+// Unique 生成不冲突的slug，exists用于判断候选slug是否已被占用
+func (g *Generator) Unique(base string, exists func(candidate string) (bool, error)) (string, error) {
+	if base == "" {
+		base = Fallback
+	}
+
+	candidate := base
+	for n := 2; n < maxAttempts; n++ {
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+
+		suffix := "-" + strconv.Itoa(n)
+		candidate = g.truncate(base, g.maxLength-len(suffix)) + suffix
+	}
+
+	return "", ErrExhausted
+}
+
+// truncate 截断到指定长度，优先在连字符处截断；limit至少为1
+func (g *Generator) truncate(s string, limit int) string {
+	limit = max(limit, 1)
+	s = strings.Trim(s, "-")
+	if len(s) <= limit {
+		return s
+	}
+
+	cut := s[:limit]
+	if i := strings.LastIndexByte(cut, '-'); i > limit/2 {
+		cut = cut[:i]
+	}
+	return strings.Trim(cut, "-")
+}
+
+// transliterate 将单个字符转换为ASCII
+func transliterate(r rune) string {
+	if r < unicode.MaxASCII {
+		return string(r)
+	}
+	if s, ok := builtinTable[r]; ok {
+		return s
+	}
+
+	// 去掉变音符号，如 é -> e、ά -> a
+	var b strings.Builder
+	for _, d := range norm.NFD.String(string(r)) {
+		if d < unicode.MaxASCII {
+			b.WriteRune(d)
+		} else if s, ok := builtinTable[d]; ok {
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
+
+// builtinTable 无法通过去除变音符号得到的常见字符
+var builtinTable = map[rune]string{
+	// 拉丁字母扩展
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ł': "l", 'þ': "th", 'ð': "d", 'ı': "i",
+
+	// 西里尔字母
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+
+	// 希腊字母
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+}
@@ -0,0 +1,131 @@
+package slug
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	g := New(Options{Transliterations: ParseTransliterations("ä=ae, ö=oe, ü=ue, invalid, xy=z")})
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  --Go 1.21 Release Notes--  ", "go-1-21-release-notes"},
+		{"全文检索", "quan-wen-jian-suo"},
+		{"Go语言入门", "go-yu-yan-ru-men"},
+		{"Café déjà vu", "cafe-deja-vu"},
+		{"Größe über Öl", "groesse-ueber-oel"},
+		{"Привет мир", "privet-mir"},
+		{"Ελληνικά", "ellinika"},
+		{"ひらがな", ""},
+		{"!!!", ""},
+	}
+	for _, tt := range tests {
+		if got := g.Make(tt.title); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestParseTransliterations(t *testing.T) {
+	table := ParseTransliterations("Ä=AE, ß=ss ,bad,xy=z,=q")
+	if len(table) != 2 || table['ä'] != "ae" || table['ß'] != "ss" {
+		t.Fatalf("ParseTransliterations() = %v", table)
+	}
+}
+
+func TestMakeMaxLength(t *testing.T) {
+	tests := []struct {
+		maxLength int
+		title     string
+		want      string
+	}{
+		// 优先在连字符处截断
+		{20, "the quick brown fox jumps over", "the-quick-brown-fox"},
+		// 连字符太靠前时直接截断
+		{10, "a verylongwordwithouthyphens", "a-verylong"},
+		{10, "short", "short"},
+		// 过小的长度按MinMaxLength处理
+		{1, "hello world again", "hello"},
+		{-1, strings.Repeat("word ", 40), strings.TrimSuffix(strings.Repeat("word-", 16), "-")},
+	}
+	for _, tt := range tests {
+		g := New(Options{MaxLength: tt.maxLength})
+		got := g.Make(tt.title)
+		if got != tt.want {
+			t.Errorf("MaxLength %d: Make(%q) = %q, want %q", tt.maxLength, tt.title, got, tt.want)
+		}
+		if len(got) > max(tt.maxLength, MinMaxLength) && tt.maxLength > 0 {
+			t.Errorf("MaxLength %d: %q is too long", tt.maxLength, got)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLength int
+		base      string
+		taken     []string
+		want      string
+	}{
+		{"free", 80, "hello", nil, "hello"},
+		{"empty base", 80, "", nil, Fallback},
+		{"first collision", 80, "hello", []string{"hello"}, "hello-2"},
+		{"several collisions", 80, "hello", []string{"hello", "hello-2", "hello-3"}, "hello-4"},
+		{"suffix fits max length", 10, "abcdefghij", []string{"abcdefghij"}, "abcdefgh-2"},
+		{"suffix at min length", MinMaxLength, "abcdefgh", []string{"abcdefgh", "abcdef-2"}, "abcdef-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(Options{MaxLength: tt.maxLength})
+			taken := make(map[string]bool)
+			for _, s := range tt.taken {
+				taken[s] = true
+			}
+			got, err := g.Unique(tt.base, func(candidate string) (bool, error) {
+				if len(candidate) > tt.maxLength {
+					t.Errorf("candidate %q exceeds max length %d", candidate, tt.maxLength)
+				}
+				return taken[candidate], nil
+			})
+			if err != nil {
+				t.Fatalf("Unique() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unique() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueLongSuffixAtMinLength(t *testing.T) {
+	g := New(Options{MaxLength: MinMaxLength})
+	got, err := g.Unique("abcdefgh", func(candidate string) (bool, error) {
+		if len(candidate) > MinMaxLength {
+			return false, fmt.Errorf("candidate %q exceeds max length", candidate)
+		}
+		return candidate != "abcd-500", nil
+	})
+	if err != nil || got != "abcd-500" {
+		t.Fatalf("Unique() = %q, %v", got, err)
+	}
+}
+
+func TestUniqueErrors(t *testing.T) {
+	g := New(Options{})
+
+	if _, err := g.Unique("hello", func(string) (bool, error) { return true, nil }); !errors.Is(err, ErrExhausted) {
+		t.Errorf("all taken: err = %v, want ErrExhausted", err)
+	}
+
+	boom := errors.New("boom")
+	if _, err := g.Unique("hello", func(string) (bool, error) { return false, boom }); !errors.Is(err, boom) {
+		t.Errorf("lookup failure: err = %v, want %v", err, boom)
+	}
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options S3兼容存储的连接参数
+type S3Options struct {
+	Endpoint  string // 不含协议的主机名和端口，如s3.amazonaws.com、localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool // 使用路径形式访问桶，MinIO等自建服务通常需要
+	// PublicURL 桶可以公开读取时的访问前缀（如CDN地址），为空时生成签名地址
+	PublicURL string
+	// URLExpiry 签名地址的有效期
+	URLExpiry time.Duration
+	// CacheControl 写入对象时设置的Cache-Control
+	CacheControl string
+}
+
+// S3 保存在S3兼容对象存储中的后端，兼容AWS S3、MinIO等
+type S3 struct {
+	client *minio.Client
+	opts   S3Options
+}
+
+// NewS3 连接对象存储并确认桶存在，桶需要预先创建
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("S3 endpoint and bucket are required")
+	}
+	if opts.URLExpiry <= 0 {
+		opts.URLExpiry = time.Hour
+	}
+	opts.PublicURL = strings.TrimRight(opts.PublicURL, "/")
+
+	lookup := minio.BucketLookupAuto
+	if opts.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       opts.UseSSL,
+		Region:       opts.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access bucket %q: %w", opts.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %q does not exist", opts.Bucket)
+	}
+
+	return &S3{client: client, opts: opts}, nil
+}
+
+// Put 上传对象，size未知时传-1
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.opts.Bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: s.opts.CacheControl,
+	})
+	return err
+}
+
+// Open 下载对象；GetObject不会立即发出请求，先Stat一次以便区分对象不存在
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.opts.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, s.translate(err)
+	}
+	return obj, nil
+}
+
+// Stat 获取对象信息
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.client.StatObject(ctx, s.opts.Bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	return &Object{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+// Delete 删除对象，S3删除不存在的对象本身不报错
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.opts.Bucket, key, minio.RemoveObjectOptions{})
+}
+
+// URL 配置了公开地址时直接拼接，否则生成有时效的签名地址
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.opts.PublicURL != "" {
+		return s.opts.PublicURL + "/" + (&url.URL{Path: key}).EscapedPath(), nil
+	}
+	signed, err := s.client.PresignedGetObject(ctx, s.opts.Bucket, key, s.opts.URLExpiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
+
+// Walk 列出键以prefix开头的全部对象
+func (s *S3) Walk(ctx context.Context, prefix string, fn func(Object) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	// 提前返回时取消列举，让后台协程退出
+	defer cancel()
+
+	for info := range s.client.ListObjects(ctx, s.opts.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return info.Err
+		}
+		if err := fn(Object{
+			Key:         info.Key,
+			Size:        info.Size,
+			ContentType: info.ContentType,
+			ModTime:     info.LastModified,
+		}); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// translate 将对象不存在的错误转换为ErrNotFound
+func (s *S3) translate(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
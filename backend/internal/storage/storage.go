@@ -0,0 +1,91 @@
+/*
+开发心理过程：
+1. 多副本部署时本地磁盘不共享，上传的文件需要放到对象存储，本地磁盘只适合单机
+2. 业务代码只依赖Storage接口，按对象键读写，不关心文件实际存放在哪里
+3. 本地实现由应用自己提供下载，S3实现返回公开地址或有时效的签名地址，由客户端直接访问对象存储
+4. 对象键与本地相对路径一致，文件可以在两种后端之间原样复制，数据库中的路径不需要改
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"techblog-api/backend/internal/config"
+)
+
+// 支持的存储后端
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("object not found")
+
+// Object 对象的基本信息
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage 上传文件的存储后端，key为使用/分隔的相对路径
+type Storage interface {
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open 读取对象内容，不存在时返回ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat 获取对象信息，不存在时返回ErrNotFound
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete 删除对象，不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// URL 客户端可以直接访问的地址，私有存储返回有时效的签名地址
+	URL(ctx context.Context, key string) (string, error)
+	// Walk 遍历键以prefix开头的全部对象
+	Walk(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+// FileSystem 对象保存在本地磁盘上的后端，可以由应用直接提供文件
+type FileSystem interface {
+	FilePath(key string) string
+}
+
+// New 按驱动名创建存储后端
+func New(cfg *config.Config, driver string) (Storage, error) {
+	switch driver {
+	case DriverLocal:
+		return NewLocal(cfg.UploadPath, "/uploads/"), nil
+	case DriverS3:
+		return NewS3(S3Options{
+			Endpoint:     cfg.S3Endpoint,
+			Region:       cfg.S3Region,
+			Bucket:       cfg.S3Bucket,
+			AccessKey:    cfg.S3AccessKey,
+			SecretKey:    cfg.S3SecretKey,
+			UseSSL:       cfg.S3UseSSL,
+			PathStyle:    cfg.S3PathStyle,
+			PublicURL:    cfg.S3PublicURL,
+			URLExpiry:    cfg.S3URLExpiry,
+			CacheControl: "public, max-age=31536000, immutable",
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// cleanKey 规范化对象键，去掉开头的/并拒绝跳出根目录的路径
+func cleanKey(key string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return cleaned, nil
+}
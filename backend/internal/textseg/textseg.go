@@ -0,0 +1,118 @@
+/*
+开发心理过程：
+1. 中日韩文字之间没有空格，PostgreSQL默认解析器会把整段汉字当成一个词
+2. 先按文字类别把文本切成若干段，slug生成和搜索索引共用这一步
+3. 搜索采用二元切分（bigram），不依赖大词典，内存占用小，中文查询可以按词命中
+4. 索引时每个字也单独收录一次，只搜一个字（如"库"）时才能命中；多字查询仍按二元短语匹配
+*/
+
+package textseg
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Version 分词规则的版本，规则变化时加一，已建立的索引按新规则重新生成
+const Version = 3
+
+// Segment 同一类文字组成的连续片段
+type Segment struct {
+	Text string
+	CJK  bool // 是否为中日韩文字片段
+}
+
+// IsCJK 判断字符是否属于中日韩文字（汉字、假名、谚文）
+func IsCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// IsHan 判断字符是否为汉字
+func IsHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// Split 将文本切分为中日韩片段和其他片段
+func Split(text string) []Segment {
+	var segments []Segment
+	var current strings.Builder
+	currentCJK := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, Segment{Text: current.String(), CJK: currentCJK})
+			current.Reset()
+		}
+	}
+
+	for _, r := range text {
+		isCJK := IsCJK(r)
+		if isCJK != currentCJK {
+			flush()
+			currentCJK = isCJK
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return segments
+}
+
+// Bigrams 将一段中日韩文字切分为相邻二元组，单字片段原样返回
+func Bigrams(run string) []string {
+	runes := []rune(run)
+	if len(runes) < 2 {
+		if len(runes) == 1 {
+			return []string{run}
+		}
+		return nil
+	}
+
+	grams := make([]string, 0, len(runes)-1)
+	for i := 0; i < len(runes)-1; i++ {
+		grams = append(grams, string(runes[i:i+2]))
+	}
+	return grams
+}
+
+// Tokens 提取文本中所有中日韩片段的二元分词和单字，以空格连接，供全文检索建立索引
+// 所有片段的二元组在前且保持原有顺序，跨片段的短语查询也能命中；单字排在其后
+func Tokens(text string) string {
+	var bigrams, unigrams []string
+	for _, seg := range Split(text) {
+		if !seg.CJK {
+			continue
+		}
+		bigrams = append(bigrams, Bigrams(seg.Text)...)
+		if runes := []rune(seg.Text); len(runes) > 1 {
+			for _, r := range runes {
+				unigrams = append(unigrams, string(r))
+			}
+		}
+	}
+	return strings.Join(append(bigrams, unigrams...), " ")
+}
+
+// Query 按索引时相同的规则改写搜索语句
+// 引号外的中日韩片段改写为"短语"，保证二元组相邻命中；单字片段按单字命中；前缀的-排除语法保持不变
+func Query(q string) string {
+	var out strings.Builder
+	inQuote := false
+
+	for _, seg := range Split(q) {
+		if !seg.CJK {
+			inQuote = inQuote != (strings.Count(seg.Text, `"`)%2 == 1)
+			out.WriteString(seg.Text)
+			continue
+		}
+
+		grams := strings.Join(Bigrams(seg.Text), " ")
+		if inQuote {
+			out.WriteString(" " + grams + " ")
+		} else {
+			out.WriteString(`"` + grams + `"`)
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,100 @@
+package textseg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	got := Split("Go语言 入门")
+	want := []Segment{{Text: "Go", CJK: false}, {Text: "语言", CJK: true}, {Text: " ", CJK: false}, {Text: "入门", CJK: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Split() = %#v, want %#v", got, want)
+	}
+}
+
+func TestBigrams(t *testing.T) {
+	tests := []struct {
+		run  string
+		want []string
+	}{
+		{"", nil},
+		{"库", []string{"库"}},
+		{"数据", []string{"数据"}},
+		{"数据库", []string{"数据", "据库"}},
+	}
+	for _, tt := range tests {
+		if got := Bigrams(tt.run); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Bigrams(%q) = %v, want %v", tt.run, got, tt.want)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hello world", ""},
+		{"库", "库"},
+		{"Go数据库", "数据 据库 数 据 库"},
+		{"数据库 设计", "数据 据库 设计 数 据 库 设 计"},
+		{"ひらがな", "ひら らが がな ひ ら が な"},
+	}
+	for _, tt := range tests {
+		if got := Tokens(tt.text); got != tt.want {
+			t.Errorf("Tokens(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"golang", "golang"},
+		{"库", `"库"`},
+		{"数据库 -测试", `"数据 据库" -"测试"`},
+		{`"数据库 设计"`, `" 数据 据库   设计 "`},
+	}
+	for _, tt := range tests {
+		if got := Query(tt.q); got != tt.want {
+			t.Errorf("Query(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+// TestPhraseMatchesIndex 查询改写出的短语必须在索引分词中相邻出现，否则websearch_to_tsquery的短语查询不会命中
+func TestPhraseMatchesIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+	}{
+		{"single rune", "常用的数据库", "库"},
+		{"single segment", "关系型数据库设计", "数据库"},
+		{"quoted phrase across segments", "数据库 设计指南", `"数据库 设计"`},
+		{"phrase after latin text", "PostgreSQL全文检索", "全文检索"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := strings.Fields(Tokens(tt.text))
+			phrase := strings.Fields(strings.Trim(Query(tt.query), `"`))
+			if !containsRun(index, phrase) {
+				t.Fatalf("tokens %v do not contain phrase %v", index, phrase)
+			}
+		})
+	}
+}
+
+// containsRun 判断phrase是否作为连续子序列出现在tokens中
+func containsRun(tokens, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if reflect.DeepEqual(tokens[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+/*
+开发心理过程：
+1. 浏览量先在内存中累加，定时批量写入数据库，避免每次访问都更新文章行
+2. 写入使用 view_count = view_count + n 原子累加，并发请求不会丢失计数
+3. 按User-Agent过滤爬虫和命令行工具
+4. 同一访客在时间窗口内重复访问只计一次；访客标识为加盐后的IP+UA摘要，
+   盐每天轮换且只存在于内存中，原始IP和UA不会被保存
+5. 同时按天累计每篇文章的浏览量，用于趋势图
+*/
+
+package views
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"techblog-api/backend/internal/models"
+)
+
+// maxVisitors 去重表的最大条目数，超过后提前清理，限制内存占用
+const maxVisitors = 100000
+
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|facebookexternalhit|embedly|preview|monitor|lighthouse|headless|phantomjs|curl|wget|python-requests|httpclient|go-http-client|java/|okhttp|axios`)
+
+// IsBot 根据User-Agent判断是否为爬虫或程序访问，空UA视为程序访问
+func IsBot(userAgent string) bool {
+	return userAgent == "" || botPattern.MatchString(userAgent)
+}
+
+// viewKey 某篇文章在某一天（UTC）的浏览量
+type viewKey struct {
+	postID uint
+	day    time.Time
+}
+
+// Counter 浏览量计数器
+type Counter struct {
+	db            *gorm.DB
+	window        time.Duration
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	pending map[viewKey]int64
+	seen    map[string]time.Time
+	salt    []byte
+	saltDay string
+}
+
+// NewCounter 创建计数器，window为去重窗口，flushInterval为写库间隔
+func NewCounter(db *gorm.DB, window, flushInterval time.Duration) *Counter {
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+	return &Counter{
+		db:            db,
+		window:        window,
+		flushInterval: flushInterval,
+		pending:       make(map[viewKey]int64),
+		seen:          make(map[string]time.Time),
+	}
+}
+
+// Record 记录一次访问，返回是否计入
+func (c *Counter) Record(postID uint, ip, userAgent string) bool {
+	if IsBot(userAgent) {
+		return false
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.window > 0 {
+		key := c.visitorKey(now, postID, ip, userAgent)
+		if last, ok := c.seen[key]; ok && now.Sub(last) < c.window {
+			return false
+		}
+		if len(c.seen) >= maxVisitors {
+			c.pruneLocked(now)
+		}
+		c.seen[key] = now
+	}
+
+	// 按访问发生时的UTC日期统计，跨零点写库时不会记到第二天
+	c.pending[viewKey{postID: postID, day: now.UTC().Truncate(24 * time.Hour)}]++
+	return true
+}
+
+// Run 按间隔写入数据库，ctx取消后写入剩余计数再返回
+func (c *Counter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 服务关闭时ctx已取消，使用新的上下文写入最后一批
+			if err := c.Flush(context.Background()); err != nil {
+				log.Printf("Failed to flush view counts: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to flush view counts: %v", err)
+			}
+		}
+	}
+}
+
+// Flush 将内存中的计数写入数据库，失败时计数放回下次重试
+func (c *Counter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	batch := c.pending
+	c.pending = make(map[viewKey]int64)
+	c.pruneLocked(time.Now())
+	c.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	totals := make(map[uint]int64, len(batch))
+	daily := make([]models.PostDailyView, 0, len(batch))
+	for key, n := range batch {
+		totals[key.postID] += n
+		daily = append(daily, models.PostDailyView{PostID: key.postID, Day: key.day, Views: n})
+	}
+	// 按ID和日期顺序更新，多个实例同时写入时不会互相死锁
+	sort.Slice(daily, func(i, j int) bool {
+		if daily[i].PostID != daily[j].PostID {
+			return daily[i].PostID < daily[j].PostID
+		}
+		return daily[i].Day.Before(daily[j].Day)
+	})
+	ids := make([]uint, 0, len(totals))
+	for postID := range totals {
+		ids = append(ids, postID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, postID := range ids {
+			if err := tx.Model(&models.BlogPost{}).Where("id = ?", postID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", totals[postID])).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("post_daily_views.views + EXCLUDED.views")}),
+		}).Create(&daily).Error
+	})
+
+	if err != nil {
+		c.mu.Lock()
+		for key, n := range batch {
+			c.pending[key] += n
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// visitorKey 生成访客摘要，盐按UTC日期轮换
+func (c *Counter) visitorKey(now time.Time, postID uint, ip, userAgent string) string {
+	if day := now.UTC().Format("2006-01-02"); day != c.saltDay {
+		c.salt = make([]byte, 32)
+		if _, err := rand.Read(c.salt); err != nil {
+			log.Printf("Failed to generate view salt: %v", err)
+		}
+		c.saltDay = day
+		// 换盐后旧摘要无法再命中
+		c.seen = make(map[string]time.Time)
+	}
+
+	h := sha256.New()
+	h.Write(c.salt)
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatUint(uint64(postID), 10)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// pruneLocked 清理超出去重窗口的访客，仍然过多时全部清空
+func (c *Counter) pruneLocked(now time.Time) {
+	for key, last := range c.seen {
+		if now.Sub(last) >= c.window {
+			delete(c.seen, key)
+		}
+	}
+	if len(c.seen) >= maxVisitors {
+		c.seen = make(map[string]time.Time)
+	}
+}
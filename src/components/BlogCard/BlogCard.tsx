@@ -41,9 +41,9 @@ const BlogCard: React.FC<BlogCardProps> = ({ post }) => {
         
         <div className={styles.footer}>
           <div className={styles.tags}>
-            {JSON.parse(post.tags || '[]').map((tag: string) => (
-              <span key={tag} className={styles.tag}>
-                {tag}
+            {(post.tags || []).map(tag => (
+              <span key={tag.id} className={styles.tag}>
+                {tag.name}
               </span>
             ))}
           </div>
@@ -2,9 +2,12 @@ import React from 'react';
 import { Outlet } from 'react-router-dom';
 import Header from '../Header/Header';
 import Footer from '../Footer/Footer';
+import { usePageAnalytics } from '../../hooks/useAnalytics';
 import styles from './Layout.module.css';
 
 const Layout: React.FC = () => {
+  usePageAnalytics();
+
   return (
     <div className={styles.layout}>
       <Header />
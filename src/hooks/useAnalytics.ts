@@ -0,0 +1,71 @@
+import { useEffect } from 'react';
+import { useLocation } from 'react-router-dom';
+
+const COLLECT_URL = '/api/v1/analytics/collect';
+
+type AnalyticsEvent = {
+  type: 'pageview' | 'leave';
+  path: string;
+  referrer?: string;
+  duration?: number;
+};
+
+// sendBeacon在页面关闭时也能送达，不支持时退回fetch keepalive
+const send = (event: AnalyticsEvent) => {
+  const body = JSON.stringify(event);
+  if (navigator.sendBeacon && navigator.sendBeacon(COLLECT_URL, body)) {
+    return;
+  }
+  fetch(COLLECT_URL, { method: 'POST', body, keepalive: true }).catch(() => undefined);
+};
+
+// 每次路由变化上报一次访问，离开页面或切换路由时上报停留秒数
+export const usePageAnalytics = () => {
+  const location = useLocation();
+
+  useEffect(() => {
+    const path = location.pathname;
+    const isFirstPage = !sessionStorage.getItem('analytics:landed');
+    sessionStorage.setItem('analytics:landed', '1');
+
+    send({
+      type: 'pageview',
+      path,
+      // 只有进入站点的第一个页面带外部来源，站内跳转不重复计算
+      referrer: isFirstPage ? document.referrer : undefined,
+    });
+
+    let visibleSince = document.visibilityState === 'visible' ? Date.now() : 0;
+    let seconds = 0;
+    let reported = false;
+
+    const pause = () => {
+      if (visibleSince) {
+        seconds += (Date.now() - visibleSince) / 1000;
+        visibleSince = 0;
+      }
+    };
+    const report = () => {
+      pause();
+      if (!reported && seconds >= 1) {
+        reported = true;
+        send({ type: 'leave', path, duration: Math.round(seconds) });
+      }
+    };
+    const onVisibilityChange = () => {
+      if (document.visibilityState === 'hidden') {
+        report();
+      } else if (!reported) {
+        visibleSince = Date.now();
+      }
+    };
+
+    document.addEventListener('visibilitychange', onVisibilityChange);
+    window.addEventListener('pagehide', report);
+    return () => {
+      document.removeEventListener('visibilitychange', onVisibilityChange);
+      window.removeEventListener('pagehide', report);
+      report();
+    };
+  }, [location.pathname]);
+};
@@ -38,10 +38,9 @@ export const useBlog = () => {
     if (!Array.isArray(posts)) {
       return [];
     }
-    return posts.filter(post => {
-      const tags = JSON.parse(post.tags || '[]');
-      return tags.includes(tag);
-    });
+    return posts.filter(post =>
+      (post.tags || []).some(t => t.name === tag || t.slug === tag)
+    );
   };
 
   const getFeaturedPosts = (count: number = 3): BlogPost[] => {
@@ -9,19 +9,13 @@ const Blog: React.FC = () => {
   const [selectedTag, setSelectedTag] = useState('');
 
   const allTags = Array.from(
-    new Set(posts.flatMap(post => {
-      try {
-        return JSON.parse(post.tags || '[]');
-      } catch {
-        return [];
-      }
-    }))
+    new Set(posts.flatMap(post => (post.tags || []).map(tag => tag.name)))
   ).sort();
 
   const filteredPosts = posts.filter(post => {
     const matchesSearch = post.title.toLowerCase().includes(searchTerm.toLowerCase()) ||
                          post.excerpt.toLowerCase().includes(searchTerm.toLowerCase());
-    const postTags = JSON.parse(post.tags || '[]');
+    const postTags = (post.tags || []).map(tag => tag.name);
     const matchesTag = selectedTag === '' || postTags.includes(selectedTag);
     return matchesSearch && matchesTag;
   });
@@ -69,9 +69,9 @@ const BlogDetail: React.FC = () => {
           </div>
           
           <div className={styles.tags}>
-            {JSON.parse(post.tags || '[]').map((tag: string) => (
-              <span key={tag} className={styles.tag}>
-                {tag}
+            {(post.tags || []).map(tag => (
+              <span key={tag.id} className={styles.tag}>
+                {tag.name}
               </span>
             ))}
           </div>
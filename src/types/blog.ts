@@ -1,14 +1,37 @@
+export interface Tag {
+  id: number;
+  name: string;
+  slug: string;
+}
+
+export interface TocHeading {
+  id: string;
+  text: string;
+  level: number;
+  children?: TocHeading[];
+}
+
+export interface ImageSource {
+  url: string;
+  width: number;
+}
+
 export interface BlogPost {
   id: number;
   title: string;
   content: string;
+  contentHtml?: string;
+  toc?: TocHeading[];
   excerpt: string;
   author: string;
   createdAt: string;
-  tags: string;
+  tags: Tag[];
   coverImage?: string;
+  coverImageSrcset?: ImageSource[];
   readTime: number;
   viewCount: number;
   published: boolean;
+  publishAt?: string | null;
+  status?: 'draft' | 'scheduled' | 'published';
   slug: string;
 }
\ No newline at end of file
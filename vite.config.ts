@@ -9,6 +9,17 @@ export default defineConfig({
         target: 'http://localhost:8080',
         changeOrigin: true,
         secure: false
+      },
+      // 订阅源、站点地图、robots.txt由后端生成
+      '^/((tags|authors)/[^/]+/)?(feed\\.xml|atom\\.xml|feed\\.json)$': {
+        target: 'http://localhost:8080',
+        changeOrigin: true,
+        secure: false
+      },
+      '^/(sitemap\\.xml(\\.gz)?|sitemaps/.*|robots\\.txt)$': {
+        target: 'http://localhost:8080',
+        changeOrigin: true,
+        secure: false
       }
     }
   },